@@ -14,11 +14,23 @@
 
 package v1alpha1
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // +kubebuilder:object:generate=false
 type AnyVaultSecretData interface {
 	GetName() string
 	GetLocation() *VaultSecretLocation
-	GetGenerator() *VaultSecretGenerator
+	GetGenerator() *VaultSecretDataGenerator
 	GetCollisionStrategy() FieldCollisionStrategy
 }
 
@@ -30,7 +42,7 @@ func (d *VaultSecretData) GetLocation() *VaultSecretLocation {
 	return d.Location
 }
 
-func (d *VaultSecretData) GetGenerator() *VaultSecretGenerator {
+func (d *VaultSecretData) GetGenerator() *VaultSecretDataGenerator {
 	return d.Generator
 }
 
@@ -46,7 +58,7 @@ func (d *VaultSecretVariable) GetLocation() *VaultSecretLocation {
 	return d.Location
 }
 
-func (d *VaultSecretVariable) GetGenerator() *VaultSecretGenerator {
+func (d *VaultSecretVariable) GetGenerator() *VaultSecretDataGenerator {
 	return d.Generator
 }
 
@@ -62,7 +74,7 @@ func (d *VaultSecretDataRef) GetLocation() *VaultSecretLocation {
 	return &VaultSecretLocation{Path: d.Path, Version: d.Version}
 }
 
-func (d *VaultSecretDataRef) GetGenerator() *VaultSecretGenerator {
+func (d *VaultSecretDataRef) GetGenerator() *VaultSecretDataGenerator {
 	return nil
 }
 
@@ -72,3 +84,297 @@ func (d *VaultSecretDataRef) GetCollisionStrategy() FieldCollisionStrategy {
 	}
 	return ErrorOnCollision
 }
+
+// BuiltinGenerators are the generators implemented by the operator itself.
+var BuiltinGenerators = []VaultSecretGeneratorName{
+	StringGenerator,
+	BytesGenerator,
+	PasswordGenerator,
+	RSAGenerator,
+	ECDSAGenerator,
+	Ed25519Generator,
+	SSHGenerator,
+	CertificateGenerator,
+	HtpasswdGenerator,
+	UUIDGenerator,
+}
+
+// IsBuiltin returns true if the generator is implemented by the operator and does not refer to a
+// VaultSecretGenerator resource.
+func (g *VaultSecretDataGenerator) IsBuiltin() bool {
+	for _, name := range BuiltinGenerators {
+		if g.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPassword returns the configuration of the password generator, falling back to the deprecated
+// positional arguments (length, digits, symbols).
+func (g *VaultSecretDataGenerator) GetPassword() *VaultSecretPasswordGenerator {
+	if g.Password != nil {
+		return g.Password
+	}
+	p := &VaultSecretPasswordGenerator{}
+	if len(g.Args) > 0 {
+		p.Length = int(g.Args[0])
+	}
+	if len(g.Args) > 1 {
+		p.Digits = int(g.Args[1])
+	}
+	if len(g.Args) > 2 {
+		p.Symbols = int(g.Args[2])
+	}
+	if p.Length == 0 && g.Name == HtpasswdGenerator {
+		p.Length = 32
+	}
+	return p
+}
+
+// GetRSA returns the configuration of the rsa generator, falling back to the deprecated positional
+// arguments (bits).
+func (g *VaultSecretDataGenerator) GetRSA() *VaultSecretRSAGenerator {
+	if g.RSA != nil {
+		return g.RSA
+	}
+	r := &VaultSecretRSAGenerator{}
+	if len(g.Args) > 0 {
+		r.Bits = int(g.Args[0])
+	}
+	return r
+}
+
+// GetECDSA returns the configuration of the ecdsa generator, falling back to the deprecated positional
+// arguments (curve size, e.g. 256 for P-256).
+func (g *VaultSecretDataGenerator) GetECDSA() *VaultSecretECDSAGenerator {
+	if g.ECDSA != nil {
+		return g.ECDSA
+	}
+	e := &VaultSecretECDSAGenerator{}
+	if len(g.Args) > 0 {
+		e.Curve = ECDSACurve(fmt.Sprintf("P-%d", g.Args[0]))
+	}
+	return e
+}
+
+// GetString returns the configuration of the string generator, falling back to the deprecated positional
+// arguments (length).
+func (g *VaultSecretDataGenerator) GetString() *VaultSecretStringGenerator {
+	if g.String != nil {
+		return g.String
+	}
+	str := &VaultSecretStringGenerator{}
+	if len(g.Args) > 0 {
+		str.Length = int(g.Args[0])
+	}
+	return str
+}
+
+// GetBytes returns the configuration of the bytes generator, falling back to the deprecated positional
+// arguments (length).
+func (g *VaultSecretDataGenerator) GetBytes() *VaultSecretBytesGenerator {
+	if g.Bytes != nil {
+		return g.Bytes
+	}
+	b := &VaultSecretBytesGenerator{Encoding: RawEncoding}
+	if len(g.Args) > 0 {
+		b.Length = int(g.Args[0])
+	}
+	return b
+}
+
+// GetSSH returns the configuration of the ssh generator with defaults applied.
+func (g *VaultSecretDataGenerator) GetSSH() *VaultSecretSSHGenerator {
+	ssh := &VaultSecretSSHGenerator{}
+	if g.SSH != nil {
+		*ssh = *g.SSH
+	}
+	ssh.VaultSecretKeyPair = ssh.VaultSecretKeyPair.withDefaults(Ed25519Key)
+	return ssh
+}
+
+// GetCertificate returns the configuration of the certificate generator with defaults applied.
+func (g *VaultSecretDataGenerator) GetCertificate() *VaultSecretCertificateGenerator {
+	cert := &VaultSecretCertificateGenerator{}
+	if g.Certificate != nil {
+		cert = g.Certificate.DeepCopy()
+	}
+	cert.VaultSecretKeyPair = cert.VaultSecretKeyPair.withDefaults(ECDSAKey)
+	if cert.Validity == nil {
+		cert.Validity = &metav1.Duration{Duration: 365 * 24 * time.Hour}
+	}
+	return cert
+}
+
+func (k VaultSecretKeyPair) withDefaults(keyType KeyType) VaultSecretKeyPair {
+	if k.KeyType == "" {
+		k.KeyType = keyType
+	}
+	if k.Bits == 0 {
+		k.Bits = 4096
+	}
+	if k.Curve == "" {
+		k.Curve = "P-256"
+	}
+	return k
+}
+
+// GeneratedPartField returns the vault field an additional output of a generator is written to.
+func GeneratedPartField(field, part string) string {
+	return field + "_" + part
+}
+
+// NextRotation returns the point in time a value generated at the given time is due for rotation.
+func (r *VaultSecretGeneratorRotation) NextRotation(last time.Time) (time.Time, error) {
+	if r.Schedule != "" {
+		schedule, err := cron.ParseStandard(r.Schedule)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return schedule.Next(last), nil
+	}
+	if r.Period != nil && r.Period.Duration > 0 {
+		return last.Add(r.Period.Duration), nil
+	}
+	return time.Time{}, errors.New("either period or schedule is required for rotation")
+}
+
+// GetRotation returns the rotation state of the given vault field or nil if it was never generated.
+func (s *VaultSecretStatus) GetRotation(path, field string) *VaultSecretRotationStatus {
+	for i := range s.Rotations {
+		if s.Rotations[i].Path == path && s.Rotations[i].Field == field {
+			return &s.Rotations[i]
+		}
+	}
+	return nil
+}
+
+// SetRotation records the point in time the given vault field was generated at.
+func (s *VaultSecretStatus) SetRotation(path, field string, t time.Time) {
+	if rotation := s.GetRotation(path, field); rotation != nil {
+		rotation.LastRotated.Time = t
+		return
+	}
+	s.Rotations = append(s.Rotations, VaultSecretRotationStatus{Path: path, Field: field, LastRotated: metav1.NewTime(t)})
+}
+
+// GetCreationPolicy returns the creation policy of the secret, defaulting to Owner.
+func (s *VaultSecretSpec) GetCreationPolicy() SecretCreationPolicy {
+	if s.CreationPolicy != "" {
+		return s.CreationPolicy
+	}
+	return OwnerCreationPolicy
+}
+
+// GetDeletionPolicy returns the deletion policy of the secret, defaulting to Delete.
+func (s *VaultSecretSpec) GetDeletionPolicy() SecretDeletionPolicy {
+	if s.DeletionPolicy != "" {
+		return s.DeletionPolicy
+	}
+	return DeleteDeletionPolicy
+}
+
+// AddGeneratedPath records that the entry at the vault path was created for generated values.
+func (s *VaultSecretStatus) AddGeneratedPath(path string) {
+	i := sort.SearchStrings(s.GeneratedPaths, path)
+	if i < len(s.GeneratedPaths) && s.GeneratedPaths[i] == path {
+		return
+	}
+	s.GeneratedPaths = append(s.GeneratedPaths, "")
+	copy(s.GeneratedPaths[i+1:], s.GeneratedPaths[i:])
+	s.GeneratedPaths[i] = path
+}
+
+// AddGeneratedFields records that generated values were written to the fields of the entry at the vault path,
+// which was not created for generated values.
+func (s *VaultSecretStatus) AddGeneratedFields(path string, fields []string) {
+	for i := range s.GeneratedFields {
+		generated := &s.GeneratedFields[i]
+		if generated.Path != path {
+			continue
+		}
+		for _, field := range fields {
+			j := sort.SearchStrings(generated.Fields, field)
+			if j < len(generated.Fields) && generated.Fields[j] == field {
+				continue
+			}
+			generated.Fields = append(generated.Fields, "")
+			copy(generated.Fields[j+1:], generated.Fields[j:])
+			generated.Fields[j] = field
+		}
+		return
+	}
+	sorted := append([]string(nil), fields...)
+	sort.Strings(sorted)
+	s.GeneratedFields = append(s.GeneratedFields, VaultSecretGeneratedFields{Path: path, Fields: sorted})
+}
+
+// ReferencesPath returns true if any data, variable or dataFrom of the VaultSecret refers to the vault path
+// or values were generated for it.
+func (vs *VaultSecret) ReferencesPath(path string) bool {
+	path = strings.Trim(path, "/")
+	matches := func(data AnyVaultSecretData) bool {
+		location := data.GetLocation()
+		return location != nil && strings.Trim(location.Path, "/") == path
+	}
+	for i := range vs.Spec.Data {
+		if matches(&vs.Spec.Data[i]) {
+			return true
+		}
+		for j := range vs.Spec.Data[i].Variables {
+			if matches(&vs.Spec.Data[i].Variables[j]) {
+				return true
+			}
+		}
+	}
+	for i := range vs.Spec.DataFrom {
+		if matches(&vs.Spec.DataFrom[i]) {
+			return true
+		}
+	}
+	for _, generated := range vs.Status.GeneratedPaths {
+		if generated == path {
+			return true
+		}
+	}
+	for _, generated := range vs.Status.GeneratedFields {
+		if generated.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// SetReadyCondition sets the Ready condition for the current generation of the VaultSecret.
+func (vs *VaultSecret) SetReadyCondition(status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&vs.Status.Conditions, metav1.Condition{
+		Type:               ReadyCondition,
+		Status:             status,
+		ObservedGeneration: vs.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// IsDryRun returns true if the VaultSecret is annotated to only render a preview of the secret.
+func (vs *VaultSecret) IsDryRun() bool {
+	return vs.Annotations[DryRunAnnotation] == "true"
+}
+
+// RotationRequested returns true if the rotation of the generated value at the location was requested with
+// the rotate annotation.
+func (vs *VaultSecret) RotationRequested(path, field string) bool {
+	requested, ok := vs.Annotations[RotateAnnotation]
+	if !ok {
+		return false
+	}
+	location := strings.Trim(path, "/") + "#" + field
+	for _, r := range strings.Split(requested, ",") {
+		r = strings.TrimSpace(r)
+		if r == "*" || strings.TrimLeft(r, "/") == location {
+			return true
+		}
+	}
+	return false
+}
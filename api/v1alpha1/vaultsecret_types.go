@@ -22,17 +22,252 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
-// +kubebuilder:validation:Enum=string;bytes;password;rsa;ecdsa;uuid
+// Name of a built-in generator or of a VaultSecretGenerator resource
+// +kubebuilder:validation:MinLength=1
 type VaultSecretGeneratorName string
 
+const (
+	StringGenerator      VaultSecretGeneratorName = "string"
+	BytesGenerator       VaultSecretGeneratorName = "bytes"
+	PasswordGenerator    VaultSecretGeneratorName = "password"
+	RSAGenerator         VaultSecretGeneratorName = "rsa"
+	ECDSAGenerator       VaultSecretGeneratorName = "ecdsa"
+	Ed25519Generator     VaultSecretGeneratorName = "ed25519"
+	SSHGenerator         VaultSecretGeneratorName = "ssh"
+	CertificateGenerator VaultSecretGeneratorName = "certificate"
+	HtpasswdGenerator    VaultSecretGeneratorName = "htpasswd"
+	UUIDGenerator        VaultSecretGeneratorName = "uuid"
+)
+
+// Additional outputs of generators, which are written next to the generated value into the vault field
+// `<field>_<part>`.
+const (
+	// PEM encoded public key of rsa, ecdsa, ed25519 and certificate generators.
+	PublicKeyPart = "public"
+	// Public key in authorized_keys format of the ssh generator.
+	AuthorizedKeyPart = "authorized_key"
+	// PEM encoded self-signed certificate of the certificate generator.
+	CertificatePart = "certificate"
+	// Bcrypt hash of the password of the htpasswd generator.
+	HashPart = "hash"
+	// Entry in htpasswd format of the htpasswd generator.
+	HtpasswdPart = "htpasswd"
+)
+
 // Configuration of secret generation
-type VaultSecretGenerator struct {
+type VaultSecretDataGenerator struct {
 	//
 	// +kubebuilder:validation:Required
 	Name VaultSecretGeneratorName `json:"name"`
-	//
-	// +kubebuilder:validation:Required
-	Args []int32 `json:"args"`
+	// Positional arguments of the generator.
+	// Deprecated: use the typed configuration of the generator instead.
+	// +optional
+	Args []int32 `json:"args,omitempty"`
+	// Configuration of the password and htpasswd generator.
+	// +optional
+	Password *VaultSecretPasswordGenerator `json:"password,omitempty"`
+	// Configuration of the rsa generator.
+	// +optional
+	RSA *VaultSecretRSAGenerator `json:"rsa,omitempty"`
+	// Configuration of the ecdsa generator.
+	// +optional
+	ECDSA *VaultSecretECDSAGenerator `json:"ecdsa,omitempty"`
+	// Configuration of the string generator.
+	// +optional
+	String *VaultSecretStringGenerator `json:"string,omitempty"`
+	// Configuration of the bytes generator.
+	// +optional
+	Bytes *VaultSecretBytesGenerator `json:"bytes,omitempty"`
+	// Configuration of the ssh generator.
+	// +optional
+	SSH *VaultSecretSSHGenerator `json:"ssh,omitempty"`
+	// Configuration of the certificate generator.
+	// +optional
+	Certificate *VaultSecretCertificateGenerator `json:"certificate,omitempty"`
+	// Configuration of the htpasswd generator.
+	// +optional
+	Htpasswd *VaultSecretHtpasswdGenerator `json:"htpasswd,omitempty"`
+	// Parameters passed to a generator defined by a VaultSecretGenerator resource.
+	// +optional
+	Params map[string]string `json:"params,omitempty"`
+	// Optional rotation of the generated value.
+	// +optional
+	Rotation *VaultSecretGeneratorRotation `json:"rotation,omitempty"`
+}
+
+// Configuration of the password generator
+type VaultSecretPasswordGenerator struct {
+	// Total length of the password.
+	// +kubebuilder:validation:Minimum=1
+	Length int `json:"length"`
+	// Number of digits in the password.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Digits int `json:"digits,omitempty"`
+	// Number of symbols in the password.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Symbols int `json:"symbols,omitempty"`
+	// Only use lower case letters.
+	// +optional
+	NoUpper bool `json:"noUpper,omitempty"`
+	// Allow characters to be repeated, defaults to true.
+	// +optional
+	// +kubebuilder:default=true
+	AllowRepeat *bool `json:"allowRepeat,omitempty"`
+	// Characters the symbols of the password are chosen from, defaults to common ASCII symbols.
+	// +optional
+	Charset string `json:"charset,omitempty"`
+	// Characters which must not occur in the password, e.g. ambiguous ones like "0O1lI".
+	// +optional
+	ExcludeCharacters string `json:"excludeCharacters,omitempty"`
+}
+
+// RepeatAllowed returns true if characters may be repeated, which is the default.
+func (p *VaultSecretPasswordGenerator) RepeatAllowed() bool {
+	return p.AllowRepeat == nil || *p.AllowRepeat
+}
+
+// +kubebuilder:validation:Enum=PKCS1;SEC1;PKCS8
+type KeyFormat string
+
+const (
+	// PKCS #1 encoding of rsa private keys.
+	PKCS1Format KeyFormat = "PKCS1"
+	// SEC 1 encoding of ecdsa private keys.
+	SEC1Format KeyFormat = "SEC1"
+	// PKCS #8 encoding of private keys.
+	PKCS8Format KeyFormat = "PKCS8"
+)
+
+// Configuration of the rsa generator
+type VaultSecretRSAGenerator struct {
+	// Size of the key in bits.
+	// +kubebuilder:validation:Minimum=1024
+	// +kubebuilder:validation:Maximum=16384
+	Bits int `json:"bits"`
+	// Encoding of the private key, either "PKCS1" (default) or "PKCS8".
+	// +optional
+	Format KeyFormat `json:"format,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=P-224;P-256;P-384;P-521
+type ECDSACurve string
+
+// Configuration of the ecdsa generator
+type VaultSecretECDSAGenerator struct {
+	// Name of the elliptic curve.
+	Curve ECDSACurve `json:"curve"`
+	// Encoding of the private key, either "SEC1" (default) or "PKCS8".
+	// +optional
+	Format KeyFormat `json:"format,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=rsa;ecdsa;ed25519
+type KeyType string
+
+const (
+	RSAKey     KeyType = "rsa"
+	ECDSAKey   KeyType = "ecdsa"
+	Ed25519Key KeyType = "ed25519"
+)
+
+// Configuration of the key of the ssh and certificate generator
+type VaultSecretKeyPair struct {
+	// Type of the key, defaults to "ed25519" for ssh and "ecdsa" for certificates.
+	// +optional
+	KeyType KeyType `json:"keyType,omitempty"`
+	// Size of rsa keys in bits, defaults to 4096.
+	// +optional
+	Bits int `json:"bits,omitempty"`
+	// Curve of ecdsa keys, defaults to "P-256".
+	// +optional
+	Curve ECDSACurve `json:"curve,omitempty"`
+}
+
+// Configuration of the ssh generator
+type VaultSecretSSHGenerator struct {
+	VaultSecretKeyPair `json:",inline"`
+	// Comment appended to the authorized key.
+	// +optional
+	Comment string `json:"comment,omitempty"`
+}
+
+// Configuration of the certificate generator
+type VaultSecretCertificateGenerator struct {
+	VaultSecretKeyPair `json:",inline"`
+	// Common name of the certificate subject.
+	// +kubebuilder:validation:MinLength=1
+	CommonName string `json:"commonName"`
+	// DNS subject alternative names.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// IP subject alternative names.
+	// +optional
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+	// Validity of the certificate, defaults to "8760h".
+	// +optional
+	Validity *metav1.Duration `json:"validity,omitempty"`
+	// Allow the certificate to sign other certificates.
+	// +optional
+	IsCA bool `json:"isCA,omitempty"`
+}
+
+// Configuration of the htpasswd generator
+type VaultSecretHtpasswdGenerator struct {
+	// User name of the htpasswd entry.
+	// +kubebuilder:validation:MinLength=1
+	Username string `json:"username"`
+	// Cost of the bcrypt hash, defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=4
+	// +kubebuilder:validation:Maximum=31
+	Cost int `json:"cost,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=raw;base64;hex
+type BytesEncoding string
+
+const (
+	// The bytes are written unencoded to the secret.
+	RawEncoding BytesEncoding = "raw"
+	// The bytes are written base64 encoded to the secret.
+	Base64Encoding BytesEncoding = "base64"
+	// The bytes are written hex encoded to the secret.
+	HexEncoding BytesEncoding = "hex"
+)
+
+// Configuration of the string generator
+type VaultSecretStringGenerator struct {
+	// Number of generated characters.
+	// +kubebuilder:validation:Minimum=1
+	Length int `json:"length"`
+	// Characters the string is chosen from, defaults to letters, digits and symbols.
+	// +optional
+	Alphabet string `json:"alphabet,omitempty"`
+}
+
+// Configuration of the bytes generator
+type VaultSecretBytesGenerator struct {
+	// Number of generated bytes.
+	// +kubebuilder:validation:Minimum=1
+	Length int `json:"length"`
+	// Encoding of the bytes in the secret, defaults to "raw".
+	// +optional
+	Encoding BytesEncoding `json:"encoding,omitempty"`
+}
+
+// Configuration of the periodic rotation of a generated value
+type VaultSecretGeneratorRotation struct {
+	// Interval after which the generated value is rotated, e.g. "720h".
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
+	// Cron expression defining when the generated value is rotated, e.g. "0 3 * * 0".
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Duration for which the replaced value stays available under the key `<name>_previous` of the secret.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 }
 
 type VaultSecretLocation struct {
@@ -59,7 +294,7 @@ type VaultSecretVariable struct {
 	Name string `json:"name"`
 	//
 	// +optional
-	Generator *VaultSecretGenerator `json:"generator,omitempty"`
+	Generator *VaultSecretDataGenerator `json:"generator,omitempty"`
 	//
 	// +kubebuilder:validation:Required
 	Location *VaultSecretLocation `json:"location,omitempty"`
@@ -72,7 +307,7 @@ type VaultSecretData struct {
 	Name string `json:"name"`
 	//
 	// +optional
-	Generator *VaultSecretGenerator `json:"generator,omitempty"`
+	Generator *VaultSecretDataGenerator `json:"generator,omitempty"`
 	//
 	// +optional
 	Location *VaultSecretLocation `json:"location,omitempty"`
@@ -117,6 +352,56 @@ type VaultSecretDataRef struct {
 	CollisionStrategy FieldCollisionStrategy `json:"collisionStrategy,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=Owner;Merge;Orphan;None
+type SecretCreationPolicy string
+
+const (
+	// The secret is created and owned by the VaultSecret, existing secrets not created by the operator are left alone.
+	OwnerCreationPolicy SecretCreationPolicy = "Owner"
+
+	// The data is merged into an existing secret, which is neither created nor owned by the VaultSecret.
+	MergeCreationPolicy SecretCreationPolicy = "Merge"
+
+	// The secret is created without an owner, so it is kept if the VaultSecret is deleted.
+	OrphanCreationPolicy SecretCreationPolicy = "Orphan"
+
+	// No secret is created or updated, only generated values are written to vault.
+	NoneCreationPolicy SecretCreationPolicy = "None"
+)
+
+// +kubebuilder:validation:Enum=Delete;Retain
+type SecretDeletionPolicy string
+
+const (
+	// The secret is deleted along with the VaultSecret.
+	DeleteDeletionPolicy SecretDeletionPolicy = "Delete"
+
+	// The secret is kept and released from the VaultSecret when it is deleted.
+	RetainDeletionPolicy SecretDeletionPolicy = "Retain"
+)
+
+// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
+type RolloutTargetKind string
+
+const (
+	DeploymentRolloutTarget  RolloutTargetKind = "Deployment"
+	StatefulSetRolloutTarget RolloutTargetKind = "StatefulSet"
+	DaemonSetRolloutTarget   RolloutTargetKind = "DaemonSet"
+)
+
+// Definition of workloads in the namespace of the VaultSecret which are restarted if the content of the secret changes.
+type VaultSecretRolloutTarget struct {
+	// Kind of the workloads.
+	// +kubebuilder:validation:Required
+	Kind RolloutTargetKind `json:"kind"`
+	// Name of the workload, either name or selector is required.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Label selector of the workloads, either name or selector is required.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
 // VaultSecretSpec defines the desired state of VaultSecret
 type VaultSecretSpec struct {
 	// Optional name of secret which is created by this object.
@@ -134,6 +419,28 @@ type VaultSecretSpec struct {
 	// Array of labels for the created secret.
 	// +optional
 	SecretLabels map[string]string `json:"secretLabels,omitempty"`
+	// Define how the secret is created and whether existing secrets are used.
+	// Valid values are:
+	// - "Owner" (default): The secret is created and owned by the VaultSecret, existing secrets not created by the operator are left alone;
+	// - "Merge": The data is merged into an existing secret, which is neither created nor owned by the VaultSecret;
+	// - "Orphan": The secret is created without an owner, so it is kept if the VaultSecret is deleted;
+	// - "None": No secret is created or updated, only generated values are written to vault
+	// +optional
+	CreationPolicy SecretCreationPolicy `json:"creationPolicy,omitempty"`
+	// Define what happens to the secret if the VaultSecret is deleted. Only secrets owned by the VaultSecret are deleted.
+	// Valid values are:
+	// - "Delete" (default): The secret is deleted along with the VaultSecret;
+	// - "Retain": The secret is kept and released from the VaultSecret
+	// +optional
+	DeletionPolicy SecretDeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Destroy the entries in vault with generated values if the VaultSecret is deleted and no other VaultSecret
+	// refers to them. All versions and the metadata of the entries are deleted permanently.
+	// +optional
+	PurgeGenerated bool `json:"purgeGenerated,omitempty"`
+	// Workloads which are restarted if the content of the secret changes. The hash of the content is set as
+	// annotation of their pod template.
+	// +optional
+	RolloutTargets []VaultSecretRolloutTarget `json:"rolloutTargets,omitempty"`
 }
 
 // VaultSecretStatus defines the observed state of VaultSecret
@@ -141,9 +448,124 @@ type VaultSecretStatus struct {
 	// Reference to the created secret object.
 	// +optional
 	SecretObject *corev1.ObjectReference `json:"active,omitempty"`
+	// Reference to the secret which was created before the secret name changed, until it was cleaned up.
+	// +optional
+	PreviousSecretObject *corev1.ObjectReference `json:"previous,omitempty"`
+	// Rotation state of generated values.
+	// +optional
+	Rotations []VaultSecretRotationStatus `json:"rotations,omitempty"`
+	// Vault paths of entries which were created for values generated for the VaultSecret.
+	// +optional
+	GeneratedPaths []string `json:"generatedPaths,omitempty"`
+	// Fields with generated values written to vault entries which existed before.
+	// +optional
+	GeneratedFields []VaultSecretGeneratedFields `json:"generatedFields,omitempty"`
+	// Conditions of the VaultSecret, the Ready condition reports whether the secret is in sync with vault.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Preview of the secret rendered in dry-run mode.
+	// +optional
+	Preview *VaultSecretPreview `json:"preview,omitempty"`
+	// Point in time the secret was created or updated at last.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// DryRunAnnotation enables the dry-run mode of a VaultSecret if set to "true". The secret is only rendered
+// into status.preview, neither the secret nor vault are written and no values are generated.
+const DryRunAnnotation = "vault.finleap.cloud/dry-run"
+
+// ForceSyncAnnotation requests a reconcile of a VaultSecret which reads all entries from vault instead of the
+// cache. It is removed once the secret was synced.
+const ForceSyncAnnotation = "vault.finleap.cloud/force-sync"
+
+// RotateAnnotation requests the rotation of generated values of a VaultSecret regardless of their schedule.
+// Its value is a comma separated list of locations as path#field or * for all generated values. It is
+// removed once the values were rotated.
+const RotateAnnotation = "vault.finleap.cloud/rotate"
+
+// GeneratedPlaceholder replaces values in a preview which do not exist yet and would be generated.
+const GeneratedPlaceholder = "<generated>"
+
+// VaultSecretPreview defines the secret rendered in dry-run mode. Values are only shown as hashes.
+type VaultSecretPreview struct {
+	// Name of the secret which would be written.
+	SecretName string `json:"secretName"`
+	// Type of the secret which would be written.
+	// +optional
+	Type corev1.SecretType `json:"type,omitempty"`
+	// Lengths of the values of the secret by key, the values themselves are redacted.
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+	// Locations of values which do not exist yet and would be generated, as path#field. They are rendered
+	// as the placeholder <generated>.
+	// +optional
+	Generated []string `json:"generated,omitempty"`
+	// Changed is true if applying the VaultSecret would create the secret or change its content.
+	// +optional
+	Changed bool `json:"changed,omitempty"`
+	// Point in time the preview was rendered at.
+	RenderedAt metav1.Time `json:"renderedAt"`
+}
+
+const (
+	// ReadyCondition reports whether the secret is in sync with vault.
+	ReadyCondition = "Ready"
+
+	// ReasonSynced means the secret is in sync with vault.
+	ReasonSynced = "Synced"
+	// ReasonSecretExists means a secret of the same name exists, which was not created by the operator.
+	ReasonSecretExists = "SecretExists"
+	// ReasonSecretMissing means the secret to merge the data into does not exist.
+	ReasonSecretMissing = "SecretMissing"
+	// ReasonSecretNotManaged means no secret is managed because of the creation policy None.
+	ReasonSecretNotManaged = "SecretNotManaged"
+	// ReasonFailed means the secret could not be built or written.
+	ReasonFailed = "Failed"
+	// ReasonRolloutFailed means the secret is up to date, but a rollout target could not be restarted.
+	ReasonRolloutFailed = "RolloutFailed"
+	// ReasonInvalid means the VaultSecret refers to an invalid vault path, generator arguments or template.
+	// It is not retried until the VaultSecret is changed.
+	ReasonInvalid = "Invalid"
+	// ReasonPermissionDenied means the operator or vault denied access to a vault path.
+	ReasonPermissionDenied = "PermissionDenied"
+	// ReasonEntryMissing means an entry or field referred to does not exist in vault and is not generated.
+	ReasonEntryMissing = "EntryMissing"
+	// ReasonVaultSealed means vault is sealed.
+	ReasonVaultSealed = "VaultSealed"
+	// ReasonVaultUnavailable means vault could not be reached or failed to handle the request.
+	ReasonVaultUnavailable = "VaultUnavailable"
+	// ReasonDryRun means the VaultSecret is in dry-run mode and the secret was only rendered into the preview.
+	ReasonDryRun = "DryRun"
+)
+
+// VaultSecretRotationStatus defines the observed rotation state of a generated value
+type VaultSecretRotationStatus struct {
+	// Vault path of the generated value.
+	Path string `json:"path"`
+	// Vault field of the generated value.
+	Field string `json:"field"`
+	// Point in time the value was generated at last.
+	LastRotated metav1.Time `json:"lastRotated"`
+}
+
+// VaultSecretGeneratedFields defines the fields written with generated values to an entry in vault, which
+// was not created by the operator.
+type VaultSecretGeneratedFields struct {
+	// Vault path of the entry.
+	Path string `json:"path"`
+	// Fields written to the entry, including additional parts of the generated values.
+	Fields []string `json:"fields"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=".status.active.name"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 
 // VaultSecret is the Schema for the vaultsecrets API
 type VaultSecret struct {
@@ -15,23 +15,138 @@
 package v1alpha1
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
 
+	"github.com/Masterminds/sprig/v3"
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // log is for logging in this package.
 var vaultsecretlog = logf.Log.WithName("webhook.vaultsecret")
 
-func (r *VaultSecret) SetupWebhookWithManager(mgr ctrl.Manager) error {
+// SetupWebhookWithManager registers the validating webhook. Server-side dry runs additionally check that
+// the referenced entries can be read from vault, if a reader is given.
+func (r *VaultSecret) SetupWebhookWithManager(mgr ctrl.Manager, vaultReader VaultReader) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&vaultSecretValidator{vault: vaultReader}).
 		Complete()
 }
 
+// ErrVaultEntryNotFound has to be returned by a VaultReader if the entry does not exist.
+var ErrVaultEntryNotFound = errors.New("vault entry not found")
+
+// VaultReader reads entries from vault. It is declared here instead of using the vault package, so the API
+// package does not depend on the vault client.
+// +kubebuilder:object:generate=false
+type VaultReader interface {
+	// GetAll returns all fields of the entry at path, ErrVaultEntryNotFound if it does not exist.
+	GetAll(ctx context.Context, path string, version int) (map[string]string, error)
+}
+
+// vaultSecretValidator validates VaultSecrets like their webhook.Validator implementation. For server-side
+// dry runs, e.g. kubectl apply --dry-run=server, it also checks that vault is reachable and all referenced
+// entries can be read.
+type vaultSecretValidator struct {
+	vault VaultReader
+}
+
+var _ admission.CustomValidator = &vaultSecretValidator{}
+
+func (v *vaultSecretValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	vs, ok := obj.(*VaultSecret)
+	if !ok {
+		return fmt.Errorf("expected a VaultSecret but got %T", obj)
+	}
+	if err := vs.ValidateCreate(); err != nil {
+		return err
+	}
+	return v.validateDryRun(ctx, vs)
+}
+
+func (v *vaultSecretValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	vs, ok := newObj.(*VaultSecret)
+	if !ok {
+		return fmt.Errorf("expected a VaultSecret but got %T", newObj)
+	}
+	if err := vs.ValidateUpdate(oldObj); err != nil {
+		return err
+	}
+	return v.validateDryRun(ctx, vs)
+}
+
+func (v *vaultSecretValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+// validateDryRun checks the vault access of the VaultSecret if the admission request is a dry run.
+func (v *vaultSecretValidator) validateDryRun(ctx context.Context, vs *VaultSecret) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || req.DryRun == nil || !*req.DryRun || v.vault == nil {
+		return nil
+	}
+	vaultsecretlog.Info("checking vault access of dry run", "name", vs.Name, "namespace", vs.Namespace)
+	if allErrs := vs.validateVaultAccess(ctx, v.vault); len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("VaultSecret").GroupKind(), vs.Name, allErrs)
+	}
+	return nil
+}
+
+// validateVaultAccess reads all entries the VaultSecret refers to. Fields of locations with a generator
+// may be missing, as they would be generated.
+func (r *VaultSecret) validateVaultAccess(ctx context.Context, reader VaultReader) field.ErrorList {
+	var allErrs field.ErrorList
+	check := func(locationPath *field.Path, location *VaultSecretLocation, generated bool) {
+		fields, err := reader.GetAll(ctx, strings.Trim(location.Path, "/"), location.Version)
+		switch {
+		case errors.Is(err, ErrVaultEntryNotFound) && generated:
+		case errors.Is(err, ErrVaultEntryNotFound):
+			allErrs = append(allErrs, field.NotFound(locationPath.Child("path"), location.Path))
+		case err != nil:
+			allErrs = append(allErrs, field.InternalError(locationPath.Child("path"), fmt.Errorf("reading from vault failed: %w", err)))
+		case location.Field == "":
+		default:
+			if _, ok := fields[location.Field]; !ok && !generated {
+				allErrs = append(allErrs, field.NotFound(locationPath.Child("field"), location.Field))
+			}
+		}
+	}
+
+	specPath := field.NewPath("spec")
+	for i, data := range r.Spec.Data {
+		dataPath := specPath.Child("data").Index(i)
+		if data.Location != nil {
+			check(dataPath.Child("location"), data.Location, data.Generator != nil)
+		}
+		for j, variable := range data.Variables {
+			if variable.Location != nil {
+				check(dataPath.Child("variables").Index(j).Child("location"), variable.Location, variable.Generator != nil)
+			}
+		}
+	}
+	for i := range r.Spec.DataFrom {
+		check(specPath.Child("dataFrom").Index(i), r.Spec.DataFrom[i].GetLocation(), false)
+	}
+	return allErrs
+}
+
 // +kubebuilder:webhook:path=/validate-vault-finleap-cloud-v1alpha1-vaultsecret,mutating=false,failurePolicy=fail,groups=vault.finleap.cloud,resources=vaultsecrets,verbs=create;update,versions=v1alpha1,name=vvaultsecret.kb.io,sideEffects=None,admissionReviewVersions=v1;v1beta1;v1alpha1
 
 var _ webhook.Validator = &VaultSecret{}
@@ -40,66 +155,179 @@ var _ webhook.Validator = &VaultSecret{}
 func (r *VaultSecret) ValidateCreate() error {
 	vaultsecretlog.Info("validating create of vaultSecret", "name", r.Name, "namespace", r.Namespace)
 
-	if (r.Spec.Data == nil || len(r.Spec.Data) == 0) && (r.Spec.DataFrom == nil || len(r.Spec.DataFrom) == 0) {
-		return errors.New("One of spec.data or spec.dataFrom is mandatory")
+	if allErrs := r.validate(); len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("VaultSecret").GroupKind(), r.Name, allErrs)
 	}
+	return nil
+}
 
-	if r.Spec.Data != nil || len(r.Spec.Data) > 0 {
-		for _, data := range r.Spec.Data {
-			if data.Name == "" {
-				return errors.New("spec.data[].name can not be empty")
+// validate returns all problems of the VaultSecret instead of only the first one.
+func (r *VaultSecret) validate() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if len(r.Spec.Data) == 0 && len(r.Spec.DataFrom) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("data"), "one of spec.data or spec.dataFrom is mandatory"))
+	}
+
+	for i, data := range r.Spec.Data {
+		dataPath := specPath.Child("data").Index(i)
+		if data.Name == "" {
+			allErrs = append(allErrs, field.Required(dataPath.Child("name"), ""))
+		}
+		if data.Location != nil {
+			if data.Variables != nil {
+				allErrs = append(allErrs, field.Forbidden(dataPath.Child("variables"), "conflicts with location"))
 			}
-			if data.Location != nil {
-				if data.Variables != nil {
-					return errors.New("spec.data[].location conflicting with spec.data[].variables")
-				}
-				if data.Template != "" {
-					return errors.New("spec.data[].location conflicting with spec.data[].template")
-				}
-				if data.Location.Path == "" || data.Location.Field == "" {
-					return errors.New("spec.data[].location.path and spec.data[].location.field are required")
-				}
-				if data.Generator != nil {
-					if data.Generator.Name == "" {
-						return errors.New("spec.data[].generator.name is required if generator is used")
-					}
-					if data.Location.Version > 0 {
-						return errors.New("spec.data[].location.version is not allowed when specifying spec.data[].generator")
-					}
-				}
-			} else {
-				if data.Template == "" {
-					return errors.New("spec.data[].template is required if spec.data[].location not provided")
-				}
-				for _, variable := range data.Variables {
-					if variable.Name == "" || variable.Location == nil {
-						return errors.New("spec.data[].variables[] requires both name and location")
-					}
-					if variable.Location.Path == "" || variable.Location.Field == "" {
-						return errors.New("spec.data[].variable[].location.path and spec.data[].variable[].location.field are required")
-					}
-					if variable.Generator != nil {
-						if variable.Generator.Name == "" {
-							return errors.New("spec.data[].variable[].generator.name is required if generator is used")
-						}
-						if variable.Location.Version > 0 {
-							return errors.New("spec.data[].variable[].location.version is not allowed when specifying spec.data[].variable[].generator")
-						}
-					}
-				}
+			if data.Template != "" {
+				allErrs = append(allErrs, field.Forbidden(dataPath.Child("template"), "conflicts with location"))
 			}
+			allErrs = append(allErrs, r.validateLocation(dataPath, data.Location, data.Generator)...)
+			continue
+		}
+		if data.Generator != nil {
+			allErrs = append(allErrs, field.Forbidden(dataPath.Child("generator"), "requires location"))
 		}
-	}
 
-	if r.Spec.DataFrom == nil || len(r.Spec.DataFrom) == 0 {
-		for _, data := range r.Spec.DataFrom {
-			if data.Path == "" {
-				return errors.New("spec.dataFrom[].path is required")
+		variables := map[string]bool{}
+		for j, variable := range data.Variables {
+			variablePath := dataPath.Child("variables").Index(j)
+			if variable.Name == "" {
+				allErrs = append(allErrs, field.Required(variablePath.Child("name"), ""))
+			} else if variables[variable.Name] {
+				allErrs = append(allErrs, field.Duplicate(variablePath.Child("name"), variable.Name))
+			}
+			variables[variable.Name] = true
+			if variable.Location == nil {
+				allErrs = append(allErrs, field.Required(variablePath.Child("location"), ""))
+				continue
 			}
+			allErrs = append(allErrs, r.validateLocation(variablePath, variable.Location, variable.Generator)...)
 		}
+
+		if data.Template == "" {
+			allErrs = append(allErrs, field.Required(dataPath.Child("template"), "required if location is not provided"))
+			continue
+		}
+		allErrs = append(allErrs, validateTemplate(dataPath.Child("template"), data.Template, variables)...)
 	}
 
-	return nil
+	for i, data := range r.Spec.DataFrom {
+		pathPath := specPath.Child("dataFrom").Index(i).Child("path")
+		if data.Path == "" {
+			allErrs = append(allErrs, field.Required(pathPath, ""))
+		} else if err := permission.Check(r.Namespace, data.Path); err != nil {
+			allErrs = append(allErrs, field.Forbidden(pathPath, err.Error()))
+		}
+	}
+
+	for i, target := range r.Spec.RolloutTargets {
+		if err := validateRolloutTarget(target); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("rolloutTargets").Index(i), target, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// validateLocation validates the location of a data entry or variable and its optional generator. The
+// namespace of the VaultSecret has to be permitted to access the path.
+func (r *VaultSecret) validateLocation(parent *field.Path, location *VaultSecretLocation, gen *VaultSecretDataGenerator) field.ErrorList {
+	var allErrs field.ErrorList
+	locationPath := parent.Child("location")
+	if location.Path == "" {
+		allErrs = append(allErrs, field.Required(locationPath.Child("path"), ""))
+	} else if err := permission.Check(r.Namespace, location.Path); err != nil {
+		allErrs = append(allErrs, field.Forbidden(locationPath.Child("path"), err.Error()))
+	}
+	if location.Field == "" {
+		allErrs = append(allErrs, field.Required(locationPath.Child("field"), ""))
+	}
+	if gen == nil {
+		return allErrs
+	}
+
+	genPath := parent.Child("generator")
+	if gen.Name == "" {
+		return append(allErrs, field.Required(genPath.Child("name"), "required if generator is used"))
+	}
+	if location.Version > 0 {
+		allErrs = append(allErrs, field.Forbidden(locationPath.Child("version"), "not allowed when using a generator"))
+	}
+	if err := validateGenerator(gen); err != nil {
+		allErrs = append(allErrs, field.Invalid(genPath, gen.Name, err.Error()))
+	}
+	return allErrs
+}
+
+// validateTemplate parses the template with the functions available to the controller and checks that
+// all variables it references are defined.
+func validateTemplate(templatePath *field.Path, text string, variables map[string]bool) field.ErrorList {
+	tmpl, err := template.New("template").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return field.ErrorList{field.Invalid(templatePath, text, err.Error())}
+	}
+
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+	for _, name := range templateReferences(tmpl.Tree.Root, true) {
+		if !variables[name] && !seen[name] {
+			allErrs = append(allErrs, field.Invalid(templatePath, text, fmt.Sprintf("references undefined variable %s", name)))
+		}
+		seen[name] = true
+	}
+	return allErrs
+}
+
+// templateReferences returns the names of all variables a template node references, either as a field
+// of dot or of $. Fields of dot are only collected while dot is the map of variables, so not within the
+// body of range and with actions.
+func templateReferences(node parse.Node, topLevel bool) []string {
+	var names []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			names = append(names, templateReferences(child, topLevel)...)
+		}
+	case *parse.ActionNode:
+		names = templateReferences(n.Pipe, topLevel)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			names = append(names, templateReferences(cmd, topLevel)...)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			names = append(names, templateReferences(arg, topLevel)...)
+		}
+	case *parse.ChainNode:
+		names = templateReferences(n.Node, topLevel)
+	case *parse.FieldNode:
+		if topLevel {
+			names = []string{n.Ident[0]}
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			names = []string{n.Ident[1]}
+		}
+	case *parse.IfNode:
+		names = append(templateReferences(n.Pipe, topLevel), templateReferences(n.List, topLevel)...)
+		names = append(names, templateReferences(n.ElseList, topLevel)...)
+	case *parse.RangeNode:
+		names = append(templateReferences(n.Pipe, topLevel), templateReferences(n.List, false)...)
+		names = append(names, templateReferences(n.ElseList, topLevel)...)
+	case *parse.WithNode:
+		names = append(templateReferences(n.Pipe, topLevel), templateReferences(n.List, false)...)
+		names = append(names, templateReferences(n.ElseList, topLevel)...)
+	case *parse.TemplateNode:
+		names = templateReferences(n.Pipe, topLevel)
+	}
+	return names
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -111,3 +339,224 @@ func (r *VaultSecret) ValidateUpdate(old runtime.Object) error {
 func (r *VaultSecret) ValidateDelete() error {
 	return nil
 }
+
+// maxGeneratorArgs is the number of deprecated positional args each built-in generator understands.
+var maxGeneratorArgs = map[VaultSecretGeneratorName]int{
+	PasswordGenerator:    3,
+	HtpasswdGenerator:    3,
+	StringGenerator:      1,
+	BytesGenerator:       1,
+	RSAGenerator:         1,
+	ECDSAGenerator:       1,
+	Ed25519Generator:     0,
+	UUIDGenerator:        0,
+	SSHGenerator:         0,
+	CertificateGenerator: 0,
+}
+
+func validateGenerator(gen *VaultSecretDataGenerator) error {
+	for _, typed := range []struct {
+		name VaultSecretGeneratorName
+		set  bool
+	}{
+		{PasswordGenerator, gen.Password != nil},
+		{RSAGenerator, gen.RSA != nil},
+		{ECDSAGenerator, gen.ECDSA != nil},
+		{StringGenerator, gen.String != nil},
+		{BytesGenerator, gen.Bytes != nil},
+		{SSHGenerator, gen.SSH != nil},
+		{CertificateGenerator, gen.Certificate != nil},
+		{HtpasswdGenerator, gen.Htpasswd != nil},
+	} {
+		if !typed.set {
+			continue
+		}
+		if typed.name != gen.Name && !(typed.name == PasswordGenerator && gen.Name == HtpasswdGenerator) {
+			return fmt.Errorf("%s configuration is not allowed for generator %s", typed.name, gen.Name)
+		}
+		if len(gen.Args) > 0 {
+			return fmt.Errorf("args are conflicting with %s configuration", typed.name)
+		}
+	}
+
+	switch gen.Name {
+	case StringGenerator:
+		str := gen.GetString()
+		if str.Length < 1 {
+			return errors.New("string requires a positive length")
+		}
+		if str.Alphabet != "" {
+			if err := util.ValidateAlphabet(str.Alphabet); err != nil {
+				return fmt.Errorf("string alphabet is invalid: %w", err)
+			}
+		}
+	case BytesGenerator:
+		b := gen.GetBytes()
+		if b.Length < 1 {
+			return errors.New("bytes requires a positive length")
+		}
+		switch b.Encoding {
+		case "", RawEncoding, Base64Encoding, HexEncoding:
+		default:
+			return fmt.Errorf("unknown bytes encoding %s", b.Encoding)
+		}
+	case PasswordGenerator:
+		if err := validatePassword(gen.GetPassword()); err != nil {
+			return err
+		}
+	case RSAGenerator:
+		if bits := gen.GetRSA().Bits; bits < 1024 || bits > 16384 {
+			return errors.New("rsa requires bits between 1024 and 16384")
+		}
+		if format := gen.GetRSA().Format; format != "" && format != PKCS1Format && format != PKCS8Format {
+			return fmt.Errorf("rsa does not support format %s", format)
+		}
+	case ECDSAGenerator:
+		if err := validateCurve(gen.GetECDSA().Curve); err != nil {
+			return err
+		}
+		if format := gen.GetECDSA().Format; format != "" && format != SEC1Format && format != PKCS8Format {
+			return fmt.Errorf("ecdsa does not support format %s", format)
+		}
+	case SSHGenerator:
+		ssh := gen.GetSSH()
+		if err := validateKeyPair(ssh.VaultSecretKeyPair); err != nil {
+			return err
+		}
+		if ssh.KeyType == ECDSAKey && ssh.Curve == "P-224" {
+			return errors.New("ssh does not support curve P-224")
+		}
+	case CertificateGenerator:
+		if gen.Certificate == nil || gen.Certificate.CommonName == "" {
+			return errors.New("certificate requires a common name")
+		}
+		cert := gen.GetCertificate()
+		if err := validateKeyPair(cert.VaultSecretKeyPair); err != nil {
+			return err
+		}
+		for _, ip := range cert.IPAddresses {
+			if net.ParseIP(ip) == nil {
+				return fmt.Errorf("certificate ip address %s is invalid", ip)
+			}
+		}
+		if cert.Validity.Duration <= 0 {
+			return errors.New("certificate requires a positive validity")
+		}
+	case HtpasswdGenerator:
+		if gen.Htpasswd == nil || gen.Htpasswd.Username == "" || strings.Contains(gen.Htpasswd.Username, ":") {
+			return errors.New("htpasswd requires a username without colons")
+		}
+		if cost := gen.Htpasswd.Cost; cost != 0 && (cost < 4 || cost > 31) {
+			return errors.New("htpasswd requires a cost between 4 and 31")
+		}
+		if err := validatePassword(gen.GetPassword()); err != nil {
+			return err
+		}
+	case Ed25519Generator, UUIDGenerator:
+	default:
+		// Any other name refers to a VaultSecretGenerator resource, which is resolved by the controller
+		if errs := validation.IsDNS1123Subdomain(string(gen.Name)); len(errs) > 0 {
+			return fmt.Errorf("generator %s is not a valid resource name: %s", gen.Name, strings.Join(errs, ", "))
+		}
+		if len(gen.Args) > 0 {
+			return fmt.Errorf("args are not supported by generator %s, use params instead", gen.Name)
+		}
+	}
+	if limit, ok := maxGeneratorArgs[gen.Name]; ok && len(gen.Args) > limit {
+		return fmt.Errorf("generator %s accepts at most %d args", gen.Name, limit)
+	}
+	for _, arg := range gen.Args {
+		if arg < 0 {
+			return errors.New("args must not be negative")
+		}
+	}
+	if len(gen.Params) > 0 && gen.IsBuiltin() {
+		return fmt.Errorf("params are not supported by built-in generator %s", gen.Name)
+	}
+
+	if err := validateRotation(gen.Rotation); err != nil {
+		return fmt.Errorf("rotation is invalid: %w", err)
+	}
+	return nil
+}
+
+func validateRolloutTarget(target VaultSecretRolloutTarget) error {
+	switch target.Kind {
+	case DeploymentRolloutTarget, StatefulSetRolloutTarget, DaemonSetRolloutTarget:
+	default:
+		return fmt.Errorf("unknown kind %s", target.Kind)
+	}
+	if (target.Name == "") == (target.Selector == nil) {
+		return errors.New("exactly one of name or selector is required")
+	}
+	if target.Selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(target.Selector); err != nil {
+			return fmt.Errorf("selector is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateKeyPair(k VaultSecretKeyPair) error {
+	switch k.KeyType {
+	case RSAKey:
+		if k.Bits < 1024 || k.Bits > 16384 {
+			return errors.New("rsa keys require bits between 1024 and 16384")
+		}
+	case ECDSAKey:
+		return validateCurve(k.Curve)
+	case Ed25519Key:
+	default:
+		return fmt.Errorf("unknown key type %s", k.KeyType)
+	}
+	return nil
+}
+
+func validateCurve(curve ECDSACurve) error {
+	switch curve {
+	case "P-224", "P-256", "P-384", "P-521":
+		return nil
+	}
+	return fmt.Errorf("unknown ecdsa curve %s", curve)
+}
+
+func validatePassword(p *VaultSecretPasswordGenerator) error {
+	if p.Length < 1 {
+		return errors.New("password requires a positive length")
+	}
+	if p.Digits < 0 || p.Symbols < 0 {
+		return errors.New("password requires a non-negative number of digits and symbols")
+	}
+	if p.Digits+p.Symbols > p.Length {
+		return errors.New("password digits and symbols exceed its length")
+	}
+	letters, digits, symbols := util.PasswordAlphabets(p.NoUpper, p.Charset, p.ExcludeCharacters)
+	if p.Length-p.Digits-p.Symbols > 0 {
+		if err := util.ValidateAlphabet(letters); err != nil {
+			return fmt.Errorf("password letters after exclusions: %w", err)
+		}
+	}
+	if (p.Digits > 0 && digits == "") || (p.Symbols > 0 && symbols == "") {
+		return errors.New("password excludes all digits or symbols")
+	}
+	if !p.RepeatAllowed() {
+		if p.Length-p.Digits-p.Symbols > len(letters) || p.Digits > len(digits) || p.Symbols > len(symbols) {
+			return errors.New("password exceeds available characters and repeats are not allowed")
+		}
+	}
+	return nil
+}
+
+func validateRotation(rotation *VaultSecretGeneratorRotation) error {
+	if rotation == nil {
+		return nil
+	}
+	if rotation.Schedule != "" && rotation.Period != nil {
+		return errors.New("period and schedule are mutually exclusive")
+	}
+	if rotation.GracePeriod != nil && rotation.GracePeriod.Duration < 0 {
+		return errors.New("gracePeriod must not be negative")
+	}
+	_, err := rotation.NextRotation(time.Now())
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VaultSecretGeneratorSpec defines how values are generated, exactly one of webhook or exec is required
+type VaultSecretGeneratorSpec struct {
+	// Generate values by calling an HTTP endpoint.
+	// +optional
+	Webhook *VaultSecretGeneratorWebhook `json:"webhook,omitempty"`
+	// Generate values by running an executable from the plugin directory of the operator.
+	// +optional
+	Exec *VaultSecretGeneratorExec `json:"exec,omitempty"`
+	// Maximum duration of a single generation, defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Configuration of a generator called via HTTP
+type VaultSecretGeneratorWebhook struct {
+	// URL the generation request is posted to.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// PEM encoded CA bundle used to verify the TLS certificate of the endpoint.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// Configuration of a generator executed locally
+type VaultSecretGeneratorExec struct {
+	// Name of the executable within the plugin directory of the operator.
+	// +kubebuilder:validation:MinLength=1
+	Command string `json:"command"`
+	// Arguments passed to the executable.
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// VaultSecretGenerator is the Schema for the vaultsecretgenerators API
+type VaultSecretGenerator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultSecretGeneratorSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultSecretGeneratorList contains a list of VaultSecretGenerator
+type VaultSecretGeneratorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultSecretGenerator `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultSecretGenerator{}, &VaultSecretGeneratorList{})
+}
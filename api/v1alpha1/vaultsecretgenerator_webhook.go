@@ -0,0 +1,87 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var vaultsecretgeneratorlog = logf.Log.WithName("webhook.vaultsecretgenerator")
+
+func (r *VaultSecretGenerator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-vault-finleap-cloud-v1alpha1-vaultsecretgenerator,mutating=false,failurePolicy=fail,groups=vault.finleap.cloud,resources=vaultsecretgenerators,verbs=create;update,versions=v1alpha1,name=vvaultsecretgenerator.kb.io,sideEffects=None,admissionReviewVersions=v1;v1beta1;v1alpha1
+
+var _ webhook.Validator = &VaultSecretGenerator{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *VaultSecretGenerator) ValidateCreate() error {
+	vaultsecretgeneratorlog.Info("validating create of vaultSecretGenerator", "name", r.Name)
+
+	for _, name := range BuiltinGenerators {
+		if VaultSecretGeneratorName(r.Name) == name {
+			return fmt.Errorf("name %s is reserved for a built-in generator", r.Name)
+		}
+	}
+	if (r.Spec.Webhook == nil) == (r.Spec.Exec == nil) {
+		return errors.New("exactly one of spec.webhook or spec.exec is required")
+	}
+	if r.Spec.Webhook != nil {
+		u, err := url.Parse(r.Spec.Webhook.URL)
+		if err != nil {
+			return fmt.Errorf("spec.webhook.url is invalid: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return errors.New("spec.webhook.url must be an absolute http or https URL")
+		}
+		if len(r.Spec.Webhook.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(r.Spec.Webhook.CABundle) {
+			return errors.New("spec.webhook.caBundle does not contain any PEM encoded certificate")
+		}
+	}
+	if r.Spec.Exec != nil {
+		command := r.Spec.Exec.Command
+		if command == "" || command == "." || command == ".." || filepath.Base(command) != command {
+			return errors.New("spec.exec.command must be the name of an executable within the plugin directory")
+		}
+	}
+	if r.Spec.Timeout != nil && r.Spec.Timeout.Duration <= 0 {
+		return errors.New("spec.timeout must be positive")
+	}
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *VaultSecretGenerator) ValidateUpdate(old runtime.Object) error {
+	vaultsecretgeneratorlog.Info("validating update of vaultSecretGenerator", "name", r.Name)
+	return r.ValidateCreate()
+}
+
+func (r *VaultSecretGenerator) ValidateDelete() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetEngine returns the secret engine secrets are imported into.
+func (s *VaultSecretImportSpec) GetEngine() string {
+	if s.Engine != "" {
+		return s.Engine
+	}
+	return "app"
+}
+
+// SetSecret records the state of the import of the secret and updates the counts.
+func (s *VaultSecretImportStatus) SetSecret(secret VaultSecretImportSecretStatus) {
+	i := sort.Search(len(s.Secrets), func(i int) bool { return s.Secrets[i].Name >= secret.Name })
+	if i < len(s.Secrets) && s.Secrets[i].Name == secret.Name {
+		s.Secrets[i] = secret
+	} else {
+		s.Secrets = append(s.Secrets, VaultSecretImportSecretStatus{})
+		copy(s.Secrets[i+1:], s.Secrets[i:])
+		s.Secrets[i] = secret
+	}
+	s.Imported, s.Failed = 0, 0
+	for _, secret := range s.Secrets {
+		switch secret.Phase {
+		case ImportedPhase:
+			s.Imported++
+		case FailedPhase:
+			s.Failed++
+		}
+	}
+}
+
+// GetSecret returns the state of the import of the secret, nil if it was not imported yet.
+func (s *VaultSecretImportStatus) GetSecret(name string) *VaultSecretImportSecretStatus {
+	for i := range s.Secrets {
+		if s.Secrets[i].Name == name {
+			return &s.Secrets[i]
+		}
+	}
+	return nil
+}
+
+// SetReadyCondition sets the Ready condition for the current generation of the VaultSecretImport.
+func (vi *VaultSecretImport) SetReadyCondition(status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&vi.Status.Conditions, metav1.Condition{
+		Type:               ReadyCondition,
+		Status:             status,
+		ObservedGeneration: vi.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VaultSecretImportSpec defines which secrets are imported into vault
+type VaultSecretImportSpec struct {
+	// Selects the secrets of the namespace to import. An empty selector selects all secrets.
+	Selector metav1.LabelSelector `json:"selector"`
+	// Secret engine the secrets are imported into, each secret at <engine>/<namespace>/<secret>.
+	// Defaults to app.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	// +optional
+	Engine string `json:"engine,omitempty"`
+}
+
+// ImportPhase is the state of the import of a single secret
+// +kubebuilder:validation:Enum=Imported;Skipped;Failed
+type ImportPhase string
+
+const (
+	// ImportedPhase means the data of the secret was written to vault and a VaultSecret adopting the
+	// secret was created.
+	ImportedPhase ImportPhase = "Imported"
+	// SkippedPhase means the secret is not imported, e.g. because it is managed by another controller.
+	SkippedPhase ImportPhase = "Skipped"
+	// FailedPhase means the import failed and is retried.
+	FailedPhase ImportPhase = "Failed"
+)
+
+// VaultSecretImportSecretStatus defines the state of the import of a single secret
+type VaultSecretImportSecretStatus struct {
+	// Name of the secret and of the VaultSecret created for it.
+	Name string `json:"name"`
+	// Vault path the data of the secret is imported to.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// State of the import.
+	Phase ImportPhase `json:"phase"`
+	// Why the secret was skipped or its import failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// VaultSecretImportStatus defines the observed state of VaultSecretImport
+type VaultSecretImportStatus struct {
+	// State of the import of each selected secret.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Secrets []VaultSecretImportSecretStatus `json:"secrets,omitempty"`
+	// Number of imported secrets.
+	Imported int32 `json:"imported"`
+	// Number of secrets whose import failed.
+	Failed int32 `json:"failed"`
+	// Conditions of the VaultSecretImport, the Ready condition reports whether all selected secrets were
+	// imported or skipped.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ReasonImported means all selected secrets were imported or skipped.
+	ReasonImported = "Imported"
+	// ReasonImportFailed means the import of some secrets failed.
+	ReasonImportFailed = "ImportFailed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Imported",type=integer,JSONPath=".status.imported"
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=".status.failed"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// VaultSecretImport is the Schema for the vaultsecretimports API
+type VaultSecretImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultSecretImportSpec   `json:"spec,omitempty"`
+	Status VaultSecretImportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VaultSecretImportList contains a list of VaultSecretImport
+type VaultSecretImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultSecretImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultSecretImport{}, &VaultSecretImportList{})
+}
@@ -22,7 +22,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -53,12 +54,58 @@ func (in *VaultSecret) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBytesGenerator) DeepCopyInto(out *VaultSecretBytesGenerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBytesGenerator.
+func (in *VaultSecretBytesGenerator) DeepCopy() *VaultSecretBytesGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBytesGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretCertificateGenerator) DeepCopyInto(out *VaultSecretCertificateGenerator) {
+	*out = *in
+	out.VaultSecretKeyPair = in.VaultSecretKeyPair
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPAddresses != nil {
+		in, out := &in.IPAddresses, &out.IPAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Validity != nil {
+		in, out := &in.Validity, &out.Validity
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretCertificateGenerator.
+func (in *VaultSecretCertificateGenerator) DeepCopy() *VaultSecretCertificateGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretCertificateGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretData) DeepCopyInto(out *VaultSecretData) {
 	*out = *in
 	if in.Generator != nil {
 		in, out := &in.Generator, &out.Generator
-		*out = new(VaultSecretGenerator)
+		*out = new(VaultSecretDataGenerator)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Location != nil {
@@ -85,6 +132,78 @@ func (in *VaultSecretData) DeepCopy() *VaultSecretData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretDataGenerator) DeepCopyInto(out *VaultSecretDataGenerator) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(VaultSecretPasswordGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RSA != nil {
+		in, out := &in.RSA, &out.RSA
+		*out = new(VaultSecretRSAGenerator)
+		**out = **in
+	}
+	if in.ECDSA != nil {
+		in, out := &in.ECDSA, &out.ECDSA
+		*out = new(VaultSecretECDSAGenerator)
+		**out = **in
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(VaultSecretStringGenerator)
+		**out = **in
+	}
+	if in.Bytes != nil {
+		in, out := &in.Bytes, &out.Bytes
+		*out = new(VaultSecretBytesGenerator)
+		**out = **in
+	}
+	if in.SSH != nil {
+		in, out := &in.SSH, &out.SSH
+		*out = new(VaultSecretSSHGenerator)
+		**out = **in
+	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(VaultSecretCertificateGenerator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Htpasswd != nil {
+		in, out := &in.Htpasswd, &out.Htpasswd
+		*out = new(VaultSecretHtpasswdGenerator)
+		**out = **in
+	}
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(VaultSecretGeneratorRotation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretDataGenerator.
+func (in *VaultSecretDataGenerator) DeepCopy() *VaultSecretDataGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretDataGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretDataRef) DeepCopyInto(out *VaultSecretDataRef) {
 	*out = *in
@@ -101,15 +220,48 @@ func (in *VaultSecretDataRef) DeepCopy() *VaultSecretDataRef {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *VaultSecretGenerator) DeepCopyInto(out *VaultSecretGenerator) {
+func (in *VaultSecretECDSAGenerator) DeepCopyInto(out *VaultSecretECDSAGenerator) {
 	*out = *in
-	if in.Args != nil {
-		in, out := &in.Args, &out.Args
-		*out = make([]int32, len(*in))
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretECDSAGenerator.
+func (in *VaultSecretECDSAGenerator) DeepCopy() *VaultSecretECDSAGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretECDSAGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratedFields) DeepCopyInto(out *VaultSecretGeneratedFields) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratedFields.
+func (in *VaultSecretGeneratedFields) DeepCopy() *VaultSecretGeneratedFields {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratedFields)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGenerator) DeepCopyInto(out *VaultSecretGenerator) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGenerator.
 func (in *VaultSecretGenerator) DeepCopy() *VaultSecretGenerator {
 	if in == nil {
@@ -120,6 +272,288 @@ func (in *VaultSecretGenerator) DeepCopy() *VaultSecretGenerator {
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretGenerator) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratorExec) DeepCopyInto(out *VaultSecretGeneratorExec) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratorExec.
+func (in *VaultSecretGeneratorExec) DeepCopy() *VaultSecretGeneratorExec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratorExec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratorList) DeepCopyInto(out *VaultSecretGeneratorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultSecretGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratorList.
+func (in *VaultSecretGeneratorList) DeepCopy() *VaultSecretGeneratorList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretGeneratorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratorRotation) DeepCopyInto(out *VaultSecretGeneratorRotation) {
+	*out = *in
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratorRotation.
+func (in *VaultSecretGeneratorRotation) DeepCopy() *VaultSecretGeneratorRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratorRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratorSpec) DeepCopyInto(out *VaultSecretGeneratorSpec) {
+	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(VaultSecretGeneratorWebhook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(VaultSecretGeneratorExec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratorSpec.
+func (in *VaultSecretGeneratorSpec) DeepCopy() *VaultSecretGeneratorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretGeneratorWebhook) DeepCopyInto(out *VaultSecretGeneratorWebhook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretGeneratorWebhook.
+func (in *VaultSecretGeneratorWebhook) DeepCopy() *VaultSecretGeneratorWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretGeneratorWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretHtpasswdGenerator) DeepCopyInto(out *VaultSecretHtpasswdGenerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretHtpasswdGenerator.
+func (in *VaultSecretHtpasswdGenerator) DeepCopy() *VaultSecretHtpasswdGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretHtpasswdGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretImport) DeepCopyInto(out *VaultSecretImport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretImport.
+func (in *VaultSecretImport) DeepCopy() *VaultSecretImport {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretImport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretImportList) DeepCopyInto(out *VaultSecretImportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultSecretImport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretImportList.
+func (in *VaultSecretImportList) DeepCopy() *VaultSecretImportList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretImportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretImportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretImportSecretStatus) DeepCopyInto(out *VaultSecretImportSecretStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretImportSecretStatus.
+func (in *VaultSecretImportSecretStatus) DeepCopy() *VaultSecretImportSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretImportSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretImportSpec) DeepCopyInto(out *VaultSecretImportSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretImportSpec.
+func (in *VaultSecretImportSpec) DeepCopy() *VaultSecretImportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretImportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretImportStatus) DeepCopyInto(out *VaultSecretImportStatus) {
+	*out = *in
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]VaultSecretImportSecretStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretImportStatus.
+func (in *VaultSecretImportStatus) DeepCopy() *VaultSecretImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretKeyPair) DeepCopyInto(out *VaultSecretKeyPair) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretKeyPair.
+func (in *VaultSecretKeyPair) DeepCopy() *VaultSecretKeyPair {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretKeyPair)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretList) DeepCopyInto(out *VaultSecretList) {
 	*out = *in
@@ -167,6 +601,121 @@ func (in *VaultSecretLocation) DeepCopy() *VaultSecretLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretPasswordGenerator) DeepCopyInto(out *VaultSecretPasswordGenerator) {
+	*out = *in
+	if in.AllowRepeat != nil {
+		in, out := &in.AllowRepeat, &out.AllowRepeat
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretPasswordGenerator.
+func (in *VaultSecretPasswordGenerator) DeepCopy() *VaultSecretPasswordGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretPasswordGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretPreview) DeepCopyInto(out *VaultSecretPreview) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Generated != nil {
+		in, out := &in.Generated, &out.Generated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.RenderedAt.DeepCopyInto(&out.RenderedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretPreview.
+func (in *VaultSecretPreview) DeepCopy() *VaultSecretPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretRSAGenerator) DeepCopyInto(out *VaultSecretRSAGenerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretRSAGenerator.
+func (in *VaultSecretRSAGenerator) DeepCopy() *VaultSecretRSAGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretRSAGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretRolloutTarget) DeepCopyInto(out *VaultSecretRolloutTarget) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretRolloutTarget.
+func (in *VaultSecretRolloutTarget) DeepCopy() *VaultSecretRolloutTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretRolloutTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretRotationStatus) DeepCopyInto(out *VaultSecretRotationStatus) {
+	*out = *in
+	in.LastRotated.DeepCopyInto(&out.LastRotated)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretRotationStatus.
+func (in *VaultSecretRotationStatus) DeepCopy() *VaultSecretRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretSSHGenerator) DeepCopyInto(out *VaultSecretSSHGenerator) {
+	*out = *in
+	out.VaultSecretKeyPair = in.VaultSecretKeyPair
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretSSHGenerator.
+func (in *VaultSecretSSHGenerator) DeepCopy() *VaultSecretSSHGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretSSHGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretSpec) DeepCopyInto(out *VaultSecretSpec) {
 	*out = *in
@@ -189,6 +738,13 @@ func (in *VaultSecretSpec) DeepCopyInto(out *VaultSecretSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.RolloutTargets != nil {
+		in, out := &in.RolloutTargets, &out.RolloutTargets
+		*out = make([]VaultSecretRolloutTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretSpec.
@@ -206,9 +762,49 @@ func (in *VaultSecretStatus) DeepCopyInto(out *VaultSecretStatus) {
 	*out = *in
 	if in.SecretObject != nil {
 		in, out := &in.SecretObject, &out.SecretObject
-		*out = new(v1.ObjectReference)
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.PreviousSecretObject != nil {
+		in, out := &in.PreviousSecretObject, &out.PreviousSecretObject
+		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
+	if in.Rotations != nil {
+		in, out := &in.Rotations, &out.Rotations
+		*out = make([]VaultSecretRotationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GeneratedPaths != nil {
+		in, out := &in.GeneratedPaths, &out.GeneratedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GeneratedFields != nil {
+		in, out := &in.GeneratedFields, &out.GeneratedFields
+		*out = make([]VaultSecretGeneratedFields, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Preview != nil {
+		in, out := &in.Preview, &out.Preview
+		*out = new(VaultSecretPreview)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretStatus.
@@ -221,12 +817,27 @@ func (in *VaultSecretStatus) DeepCopy() *VaultSecretStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretStringGenerator) DeepCopyInto(out *VaultSecretStringGenerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretStringGenerator.
+func (in *VaultSecretStringGenerator) DeepCopy() *VaultSecretStringGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretStringGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretVariable) DeepCopyInto(out *VaultSecretVariable) {
 	*out = *in
 	if in.Generator != nil {
 		in, out := &in.Generator, &out.Generator
-		*out = new(VaultSecretGenerator)
+		*out = new(VaultSecretDataGenerator)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Location != nil {
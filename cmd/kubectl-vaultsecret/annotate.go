@@ -0,0 +1,89 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+// runForceSync requests a sync of VaultSecrets which reads all entries from vault instead of the cache.
+func runForceSync(args []string, stdout io.Writer) error {
+	var kube kubeFlags
+	fs := flag.NewFlagSet("force-sync", flag.ContinueOnError)
+	kube.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return annotate(stdout, kube, fs.Args(), vaultv1alpha1.ForceSyncAnnotation, time.Now().UTC().Format(time.RFC3339), "sync requested")
+}
+
+// runRotate requests the rotation of generated values of VaultSecrets regardless of their schedule.
+func runRotate(args []string, stdout io.Writer) error {
+	var (
+		locations string
+		kube      kubeFlags
+	)
+	fs := flag.NewFlagSet("rotate", flag.ContinueOnError)
+	fs.StringVar(&locations, "field", "*", "Comma separated locations of the generated values to rotate as path#field, all by default.")
+	kube.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	for _, location := range strings.Split(locations, ",") {
+		if location = strings.TrimSpace(location); location != "*" && !strings.Contains(location, "#") {
+			return fmt.Errorf("location %s is not of the form path#field", location)
+		}
+	}
+	return annotate(stdout, kube, fs.Args(), vaultv1alpha1.RotateAnnotation, locations, "rotation requested")
+}
+
+// annotate sets the annotation on the named VaultSecrets. The operator removes it once it was handled.
+func annotate(stdout io.Writer, kube kubeFlags, names []string, key, value, done string) error {
+	if len(names) == 0 {
+		return errors.New("at least one VaultSecret is required")
+	}
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, name := range names {
+		vaultSecret := &vaultv1alpha1.VaultSecret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, vaultSecret); err != nil {
+			return err
+		}
+		base := vaultSecret.DeepCopy()
+		if vaultSecret.Annotations == nil {
+			vaultSecret.Annotations = map[string]string{}
+		}
+		vaultSecret.Annotations[key] = value
+		if err := c.Patch(ctx, vaultSecret, client.MergeFrom(base)); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "vaultsecret/%s %s\n", name, done)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command kubectl-vaultsecret inspects and manages VaultSecrets. Installed in the PATH it is available as
+// the kubectl plugin "kubectl vaultsecret".
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(vaultv1alpha1.AddToScheme(scheme))
+}
+
+// command is a subcommand of the plugin.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"render", "render -f FILE [flags]\tRender the secret of a VaultSecret manifest locally", runRender},
+	{"status", "status [NAME...] [flags]\tShow the sync state of VaultSecrets", runStatus},
+	{"force-sync", "force-sync NAME... [flags]\tSync VaultSecrets reading all entries from vault", runForceSync},
+	{"rotate", "rotate NAME... [flags]\tRotate generated values of VaultSecrets now", runRotate},
+	{"migrate", "migrate NAME... [flags]\tUpload secrets to vault and replace them by VaultSecrets", runMigrate},
+}
+
+func main() {
+	// The vault client logs through controller-runtime, only errors returned by it are of interest here
+	ctrl.SetLogger(logr.Discard())
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kubectl vaultsecret COMMAND [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'kubectl vaultsecret COMMAND -h' for the flags of a command.")
+}
+
+// kubeFlags are the flags of commands accessing the cluster.
+type kubeFlags struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+func (k *kubeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file, defaults to $KUBECONFIG or ~/.kube/config.")
+	fs.StringVar(&k.context, "context", "", "Name of the kubeconfig context to use.")
+	fs.StringVar(&k.namespace, "n", "", "Namespace of the objects, defaults to the namespace of the context.")
+}
+
+// client returns a client for the cluster and the namespace to use.
+func (k *kubeFlags) client() (client.Client, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = k.kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: k.context,
+		Context:        clientcmdapi.Context{Namespace: k.namespace},
+	})
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", err
+	}
+	return c, namespace, nil
+}
+
+// vaultFlags are the flags of commands accessing vault.
+type vaultFlags struct {
+	addr      string
+	token     string
+	namespace string
+}
+
+func (v *vaultFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&v.addr, "vault-addr", os.Getenv("VAULT_ADDR"), "Address of vault, defaults to $VAULT_ADDR.")
+	fs.StringVar(&v.token, "vault-token", os.Getenv("VAULT_TOKEN"), "Token used to connect to vault, defaults to $VAULT_TOKEN.")
+	fs.StringVar(&v.namespace, "vault-namespace", os.Getenv("VAULT_NAMESPACE"), "Namespace of vault, defaults to $VAULT_NAMESPACE.")
+}
+
+func (v *vaultFlags) client() (*vault.Client, error) {
+	if v.addr == "" {
+		return nil, fmt.Errorf("vault address is required, set -vault-addr or $VAULT_ADDR")
+	}
+	return vault.NewClient(context.Background(), v.addr, v.namespace, &vault.TokenAuth{Token: v.token})
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/controllers"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+// runMigrate uploads the data of secrets to vault and creates VaultSecrets of the same name managing them.
+func runMigrate(args []string, stdout io.Writer) error {
+	var (
+		engine    string
+		policy    string
+		dryRun    bool
+		force     bool
+		kube      kubeFlags
+		vaultOpts vaultFlags
+	)
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.StringVar(&engine, "engine", "app", "Secret engine the data is uploaded to, at <engine>/<namespace>/<name>.")
+	fs.StringVar(&policy, "creation-policy", string(vaultv1alpha1.MergeCreationPolicy), "Creation policy of the VaultSecrets. The default Merge keeps the existing secrets as they are.")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only print the VaultSecrets, nothing is written to vault or the cluster.")
+	fs.BoolVar(&force, "force", false, "Overwrite fields which exist in vault with other values instead of failing.")
+	kube.register(fs)
+	vaultOpts.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one secret is required")
+	}
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+	var vc *vault.Client
+	if !dryRun {
+		if vc, err = vaultOpts.client(); err != nil {
+			return err
+		}
+		defer vc.Close()
+	}
+
+	ctx := context.Background()
+	for _, name := range fs.Args() {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			return err
+		}
+		if owner := metav1.GetControllerOf(secret); owner != nil {
+			return fmt.Errorf("secret %s is managed by %s %s", name, owner.Kind, owner.Name)
+		}
+		path := strings.Join([]string{strings.Trim(engine, "/"), namespace, name}, "/")
+		vaultSecret := controllers.ImportedVaultSecret(secret, path, vaultv1alpha1.SecretCreationPolicy(policy))
+		if err := vaultSecret.ValidateCreate(); err != nil {
+			return err
+		}
+
+		if dryRun {
+			out, err := yaml.Marshal(vaultSecret)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "---\n%s", out)
+			continue
+		}
+		if err := upload(ctx, vc, path, controllers.ImportedVaultData(secret), force); err != nil {
+			if errors.Is(err, controllers.ErrImportConflict) {
+				return fmt.Errorf("uploading secret %s failed: %w, use -force to overwrite it", name, err)
+			}
+			return fmt.Errorf("uploading secret %s to %s failed: %w", name, path, err)
+		}
+		if err := c.Create(ctx, vaultSecret); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "secret/%s migrated to %s\n", name, path)
+	}
+	return nil
+}
+
+// upload writes the data to the vault path. Unless force is set nothing is written if a field exists with another
+// value, like the VaultSecretImport controller does.
+func upload(ctx context.Context, vc *vault.Client, path string, data map[string]interface{}, force bool) error {
+	if force {
+		return vc.CreateOrUpdate(ctx, path, data)
+	}
+	result, err := vc.WriteConditional(ctx, path, []vault.ConditionalWrite{{
+		Condition: func(fields map[string]string) bool {
+			return controllers.ImportConflict(path, fields, data) == nil
+		},
+		Data: data,
+	}})
+	if err != nil {
+		return err
+	}
+	if !result.Applied[0] {
+		return controllers.ImportConflict(path, result.Fields, data)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/controllers"
+)
+
+func newSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test", Labels: map[string]string{"app": "db"}},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("secret"),
+			"key":      {0xff, 0x00},
+		},
+	}
+}
+
+func TestToVaultSecret(t *testing.T) {
+	t.Setenv("ALLOWED_ENGINES", "app")
+	vs := controllers.ImportedVaultSecret(newSecret(), "app/test/db", vaultv1alpha1.MergeCreationPolicy)
+	if err := vs.ValidateCreate(); err != nil {
+		t.Fatalf("VaultSecret is invalid: %v", err)
+	}
+	if vs.Name != "db" || vs.Namespace != "test" {
+		t.Errorf("unexpected VaultSecret %s/%s", vs.Namespace, vs.Name)
+	}
+	if vs.Spec.SecretType != corev1.SecretTypeBasicAuth || vs.Spec.SecretLabels["app"] != "db" {
+		t.Errorf("type and labels of the secret are not kept: %v %v", vs.Spec.SecretType, vs.Spec.SecretLabels)
+	}
+	var names []string
+	for _, data := range vs.Spec.Data {
+		names = append(names, data.Name)
+		if data.Location.Path != "app/test/db" || data.Location.Field != data.Name {
+			t.Errorf("unexpected location %s#%s of %s", data.Location.Path, data.Location.Field, data.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "key,password,username" {
+		t.Errorf("expected sorted keys, got %s", got)
+	}
+}
+
+func TestToVaultData(t *testing.T) {
+	data := controllers.ImportedVaultData(newSecret())
+	expected := map[string]string{
+		"username":      "admin",
+		"password":      "secret",
+		"key":           "/wA=",
+		".key_isBinary": "1",
+	}
+	if len(data) != len(expected) {
+		t.Errorf("expected %d fields, got %v", len(expected), data)
+	}
+	for k, v := range expected {
+		if data[k] != v {
+			t.Errorf("expected %s to be %q, got %q", k, v, data[k])
+		}
+	}
+}
+
+func TestImportConflict(t *testing.T) {
+	data := controllers.ImportedVaultData(newSecret())
+	for _, test := range []struct {
+		fields   map[string]string
+		conflict string
+	}{
+		{fields: nil},
+		{fields: map[string]string{"other": "kept"}},
+		{fields: map[string]string{"username": "admin", "password": "secret", "other": "kept"}},
+		{fields: map[string]string{"username": "root"}, conflict: "username"},
+		{fields: map[string]string{"password": "other", "username": "root"}, conflict: "password"},
+		{fields: map[string]string{"key": "AAA="}, conflict: "key"},
+	} {
+		err := controllers.ImportConflict("app/test/db", test.fields, data)
+		if test.conflict == "" {
+			if err != nil {
+				t.Errorf("expected no conflict with %v, got %v", test.fields, err)
+			}
+			continue
+		}
+		if !errors.Is(err, controllers.ErrImportConflict) || !strings.HasSuffix(err.Error(), "app/test/db#"+test.conflict) {
+			t.Errorf("expected conflict of %s with %v, got %v", test.conflict, test.fields, err)
+		}
+	}
+}
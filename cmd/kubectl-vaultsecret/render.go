@@ -0,0 +1,144 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/controllers"
+)
+
+// runRender renders the secret of a VaultSecret manifest like the operator in dry-run mode, reading the
+// entries from vault with the given token. Nothing is written to the cluster or vault.
+func runRender(args []string, stdout io.Writer) error {
+	var (
+		file      string
+		namespace string
+		reveal    bool
+		vaultOpts vaultFlags
+	)
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.StringVar(&file, "f", "", "File containing the VaultSecret, - reads it from stdin.")
+	fs.StringVar(&namespace, "n", "", "Namespace the VaultSecret is rendered for, defaults to its namespace or default.")
+	fs.BoolVar(&reveal, "reveal", false, "Show the values of the secret instead of their SHA-256 hashes.")
+	vaultOpts.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if file == "" {
+		return errors.New("a file is required, set -f")
+	}
+
+	vaultSecret, err := readVaultSecret(file)
+	if err != nil {
+		return err
+	}
+	switch {
+	case namespace != "":
+		vaultSecret.Namespace = namespace
+	case vaultSecret.Namespace == "":
+		vaultSecret.Namespace = "default"
+	}
+	// Vault paths are checked against the namespace, which requires $ALLOWED_ENGINES and $SHARED_PATHS
+	// to be set like for the operator
+	if err := vaultSecret.ValidateCreate(); err != nil {
+		return err
+	}
+
+	vc, err := vaultOpts.client()
+	if err != nil {
+		return err
+	}
+	defer vc.Close()
+	r := &controllers.VaultSecretReconciler{
+		Vault:    vc,
+		Log:      ctrl.Log,
+		Recorder: &record.FakeRecorder{},
+	}
+	secret, generated, err := r.Render(context.Background(), vaultSecret, secretKey(vaultSecret))
+	if err != nil {
+		return err
+	}
+	for _, location := range generated {
+		fmt.Fprintf(os.Stderr, "%s does not exist yet and would be generated\n", location)
+	}
+	if !reveal {
+		redact(secret)
+	}
+
+	out, err := yaml.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(out)
+	return err
+}
+
+// readVaultSecret reads a VaultSecret manifest from the file or stdin.
+func readVaultSecret(file string) (*vaultv1alpha1.VaultSecret, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if file == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	vaultSecret := &vaultv1alpha1.VaultSecret{}
+	if err := yaml.UnmarshalStrict(raw, vaultSecret); err != nil {
+		return nil, fmt.Errorf("%s is not a valid VaultSecret: %w", file, err)
+	}
+	if vaultSecret.Kind != "VaultSecret" {
+		return nil, fmt.Errorf("%s contains a %s instead of a VaultSecret", file, vaultSecret.Kind)
+	}
+	return vaultSecret, nil
+}
+
+// secretKey returns the key of the secret managed by the VaultSecret.
+func secretKey(vaultSecret *vaultv1alpha1.VaultSecret) types.NamespacedName {
+	name := vaultSecret.Spec.SecretName
+	if name == "" {
+		name = vaultSecret.Name
+	}
+	return types.NamespacedName{Namespace: vaultSecret.Namespace, Name: name}
+}
+
+// redact replaces the values of the secret by their lengths.
+func redact(secret *corev1.Secret) {
+	if len(secret.Data) == 0 {
+		return
+	}
+	secret.StringData = make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		secret.StringData[key] = controllers.RedactValue(value)
+	}
+	secret.Data = nil
+}
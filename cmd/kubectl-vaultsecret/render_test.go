@@ -0,0 +1,42 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+func TestRedact(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"pin":       []byte("1234"),
+		"generated": []byte(vaultv1alpha1.GeneratedPlaceholder),
+	}}
+	redact(secret)
+
+	if secret.Data != nil {
+		t.Errorf("expected data to be removed, got %v", secret.Data)
+	}
+	if got := secret.StringData["pin"]; got != "<redacted, 4 bytes>" || strings.Contains(got, "1234") {
+		t.Errorf("expected value to be redacted, got %q", got)
+	}
+	if got := secret.StringData["generated"]; got != vaultv1alpha1.GeneratedPlaceholder {
+		t.Errorf("expected placeholder to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+// runStatus prints the Ready condition and the last sync of VaultSecrets.
+func runStatus(args []string, stdout io.Writer) error {
+	var (
+		allNamespaces bool
+		kube          kubeFlags
+	)
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.BoolVar(&allNamespaces, "A", false, "Show VaultSecrets of all namespaces.")
+	kube.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var vaultSecrets []vaultv1alpha1.VaultSecret
+	if fs.NArg() > 0 {
+		for _, name := range fs.Args() {
+			vaultSecret := vaultv1alpha1.VaultSecret{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &vaultSecret); err != nil {
+				return err
+			}
+			vaultSecrets = append(vaultSecrets, vaultSecret)
+		}
+	} else {
+		list := &vaultv1alpha1.VaultSecretList{}
+		var opts []client.ListOption
+		if !allNamespaces {
+			opts = append(opts, client.InNamespace(namespace))
+		}
+		if err := c.List(ctx, list, opts...); err != nil {
+			return err
+		}
+		vaultSecrets = list.Items
+	}
+	return printStatus(stdout, vaultSecrets, time.Now())
+}
+
+func printStatus(out io.Writer, vaultSecrets []vaultv1alpha1.VaultSecret, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSECRET\tREADY\tREASON\tLAST SYNC\tMESSAGE")
+	for i := range vaultSecrets {
+		vs := &vaultSecrets[i]
+		ready, reason, message := "Unknown", "", ""
+		if condition := meta.FindStatusCondition(vs.Status.Conditions, vaultv1alpha1.ReadyCondition); condition != nil {
+			ready, reason, message = string(condition.Status), condition.Reason, condition.Message
+			if condition.ObservedGeneration != vs.Generation {
+				// The current generation was not reconciled yet
+				ready = "Unknown"
+			}
+		}
+		lastSync := "<never>"
+		if vs.Status.LastSyncTime != nil {
+			lastSync = duration.HumanDuration(now.Sub(vs.Status.LastSyncTime.Time)) + " ago"
+		}
+		secret := "<none>"
+		if vs.Status.SecretObject != nil {
+			secret = vs.Status.SecretObject.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", vs.Namespace, vs.Name, secret, ready, reason, lastSync, message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+func TestPrintStatus(t *testing.T) {
+	now := time.Now()
+	synced := vaultv1alpha1.VaultSecret{ObjectMeta: metav1.ObjectMeta{Name: "synced", Namespace: "test", Generation: 2}}
+	synced.Status.SecretObject = &corev1.ObjectReference{Name: "db"}
+	synced.Status.LastSyncTime = &metav1.Time{Time: now.Add(-5 * time.Minute)}
+	synced.SetReadyCondition(metav1.ConditionTrue, vaultv1alpha1.ReasonSynced, "Secret db is in sync with vault")
+	outdated := *synced.DeepCopy()
+	outdated.Name = "outdated"
+	outdated.Generation = 3
+	pending := vaultv1alpha1.VaultSecret{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "test"}}
+
+	out := &bytes.Buffer{}
+	if err := printStatus(out, []vaultv1alpha1.VaultSecret{synced, outdated, pending}, now); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 rows, got:\n%s", out)
+	}
+	for i, expected := range [][]string{
+		{"synced", "db", "True", "Synced", "5m ago"},
+		{"outdated", "db", "Unknown", "Synced"},
+		{"pending", "<none>", "Unknown", "<never>"},
+	} {
+		for _, e := range expected {
+			if !strings.Contains(lines[i+1], e) {
+				t.Errorf("expected %q in row %q", e, lines[i+1])
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+// handleDryRun renders the secret of a VaultSecret in dry-run mode into its status. Neither the secret nor
+// vault are written and values which do not exist yet are rendered as placeholders instead of being generated.
+func (r *VaultSecretReconciler) handleDryRun(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret, n types.NamespacedName) error {
+	existingVaultSecret := vaultSecret.DeepCopy()
+
+	secret, generated, err := r.Render(ctx, vaultSecret, n)
+	if err != nil {
+		log.Error(err, "dry run failed")
+		reason := classify(err).reason
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, reason, fmt.Sprintf("Dry run failed: %v", err))
+		vaultSecret.Status.Preview = nil
+		return r.failed(ctx, existingVaultSecret, vaultSecret, reason, err)
+	}
+	existing := &corev1.Secret{}
+	changed := true
+	if err := r.Get(ctx, n, existing); err == nil {
+		changed = existing.Annotations[secretHashAnnotation] != secretHash(secret)
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+	setPreview(vaultSecret, secret, generated, changed)
+
+	log.V(1).Info("rendered preview of secret", "secret", n.Name)
+	vaultSecret.SetReadyCondition(metav1.ConditionFalse, vaultv1alpha1.ReasonDryRun, fmt.Sprintf("Rendered a preview of secret %s, nothing was written", n.Name))
+	return r.updateStatus(ctx, log, existingVaultSecret, vaultSecret)
+}
+
+// Render renders the secret of the VaultSecret without writing to kubernetes or vault. Values which do not
+// exist yet are not generated but rendered as placeholders, their locations are returned as path#field.
+func (r *VaultSecretReconciler) Render(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, n types.NamespacedName) (*corev1.Secret, []string, error) {
+	reads := r.Vault.NewReadCache()
+	generated, err := r.previewGeneratedValues(ctx, vaultSecret, reads)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: n.Name, Namespace: n.Namespace},
+	}
+	if err := r.renderSecret(ctx, secret, vaultSecret, reads, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	if vaultSecret.Spec.GetCreationPolicy() == vaultv1alpha1.MergeCreationPolicy {
+		// The type of the existing secret is kept
+		secret.Type = ""
+	}
+	return secret, generated, nil
+}
+
+// setPreview records the rendered secret in the status of the VaultSecret with its values redacted, changed tells
+// whether writing it would change the existing secret. The time of the previous preview is kept if nothing changed,
+// so the status is not written again.
+func setPreview(vaultSecret *vaultv1alpha1.VaultSecret, secret *corev1.Secret, generated []string, changed bool) {
+	preview := &vaultv1alpha1.VaultSecretPreview{
+		SecretName: secret.Name,
+		Type:       secret.Type,
+		Generated:  generated,
+		Changed:    changed,
+		RenderedAt: metav1.Now(),
+	}
+	if len(secret.Data) > 0 {
+		preview.Data = make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			preview.Data[key] = RedactValue(value)
+		}
+	}
+	if previous := vaultSecret.Status.Preview; previous != nil {
+		preview.RenderedAt = previous.RenderedAt
+		if !equality.Semantic.DeepEqual(previous, preview) {
+			preview.RenderedAt = metav1.Now()
+		}
+	}
+	vaultSecret.Status.Preview = preview
+}
+
+// previewGeneratedValues records placeholders in the read cache for the generated values of the VaultSecret
+// which do not exist yet, including their additional parts. It returns their locations as path#field.
+func (r *VaultSecretReconciler) previewGeneratedValues(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, reads *vault.ReadCache) ([]string, error) {
+	var generated []string
+	var locations []*vaultv1alpha1.VaultSecretLocation
+	pending := map[string][]string{}
+
+	collect := func(data vaultv1alpha1.AnyVaultSecretData) error {
+		location := data.GetLocation()
+		if location == nil {
+			return nil
+		}
+		locations = append(locations, location)
+		if data.GetGenerator() == nil {
+			return nil
+		}
+		path := strings.Trim(location.Path, "/")
+		if err := r.checkPermission(vaultSecret, path); err != nil {
+			return err
+		}
+		if _, err := reads.Get(ctx, path, location.Field, 0); err != vault.ErrNotFound {
+			return err
+		}
+		if !containsString(pending[path], location.Field) {
+			pending[path] = append(pending[path], location.Field)
+			generated = append(generated, path+"#"+location.Field)
+		}
+		return nil
+	}
+	for i := range vaultSecret.Spec.Data {
+		data := &vaultSecret.Spec.Data[i]
+		if err := collect(data); err != nil {
+			return nil, err
+		}
+		for j := range data.Variables {
+			if err := collect(&data.Variables[j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Additional parts of generated values are stored in fields prefixed by the field of the value
+	isGenerated := func(path, field string) bool {
+		for _, f := range pending[path] {
+			if field == f || strings.HasPrefix(field, f+"_") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, location := range locations {
+		path := strings.Trim(location.Path, "/")
+		if location.Version > 0 || !isGenerated(path, location.Field) {
+			continue
+		}
+		fields, err := reads.GetAll(ctx, path, 0)
+		if err != nil && err != vault.ErrNotFound {
+			return nil, err
+		}
+		if _, ok := fields[location.Field]; ok {
+			continue
+		}
+		preview := map[string]string{location.Field: vaultv1alpha1.GeneratedPlaceholder}
+		for k, v := range fields {
+			preview[k] = v
+		}
+		reads.SetLatest(path, preview)
+	}
+	return generated, nil
+}
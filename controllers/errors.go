@@ -16,11 +16,89 @@ package controllers
 
 import (
 	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/generator"
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/vault"
 )
 
 var (
-	ErrUnknownGenerator     = errors.New("no generator specified")
-	ErrInvalidGeneratorArgs = errors.New("invalid arguments for specified generator")
-	ErrInvalidVaultPath     = errors.New("invalid vault path, shoud contain at least 3 segments")
-	ErrPermissionDenied     = errors.New("permission denied by VaultOperator")
+	ErrUnknownGenerator     = generator.ErrUnknownGenerator
+	ErrInvalidGeneratorArgs = generator.ErrInvalidArgs
+	ErrInvalidVaultPath     = permission.ErrInvalidVaultPath
+	ErrPermissionDenied     = permission.ErrPermissionDenied
+	ErrSecretMissing        = errors.New("secret to merge into does not exist")
+	ErrImportConflict       = errors.New("vault field exists with a different value")
+	ErrInvalidTemplate      = errors.New("invalid template")
+)
+
+const (
+	// unavailableRetryInterval is the delay before retrying if vault failed with a 5xx response or could
+	// not be reached.
+	unavailableRetryInterval = 5 * time.Second
+	// sealedRetryInterval is the delay before retrying if vault is sealed, which needs an operator to unseal it.
+	sealedRetryInterval = 30 * time.Second
+	// forbiddenRetryInterval is the delay before retrying if vault denied access. Policies may be changed
+	// in vault without the VaultSecret changing, so these are retried rarely.
+	forbiddenRetryInterval = 5 * time.Minute
 )
+
+// failure describes how a failed reconcile is reported and retried.
+type failure struct {
+	// reason is used for the ready condition and the warning event.
+	reason string
+	// permanent failures are not retried until the VaultSecret is changed.
+	permanent bool
+	// retryAfter requeues the request after a fixed delay instead of the rate limited backoff if not zero.
+	retryAfter time.Duration
+}
+
+// classify returns how the error of a failed reconcile is reported and retried.
+func classify(err error) failure {
+	var unavailable *vault.UnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		// Requests to vault are stopped by the circuit breaker until it lets requests through again
+		return failure{reason: vaultv1alpha1.ReasonVaultUnavailable, retryAfter: unavailable.RetryAfter}
+	case errors.Is(err, ErrPermissionDenied):
+		return failure{reason: vaultv1alpha1.ReasonPermissionDenied, permanent: true}
+	case errors.Is(err, ErrInvalidVaultPath), errors.Is(err, ErrInvalidGeneratorArgs), errors.Is(err, ErrInvalidTemplate):
+		return failure{reason: vaultv1alpha1.ReasonInvalid, permanent: true}
+	case errors.Is(err, vault.ErrForbidden):
+		return failure{reason: vaultv1alpha1.ReasonPermissionDenied, retryAfter: forbiddenRetryInterval}
+	case errors.Is(err, vault.ErrInvalidRequest):
+		return failure{reason: vaultv1alpha1.ReasonInvalid, permanent: true}
+	case errors.Is(err, vault.ErrSealed):
+		return failure{reason: vaultv1alpha1.ReasonVaultSealed, retryAfter: sealedRetryInterval}
+	case errors.Is(err, vault.ErrUnavailable):
+		return failure{reason: vaultv1alpha1.ReasonVaultUnavailable, retryAfter: unavailableRetryInterval}
+	case errors.Is(err, vault.ErrNotFound):
+		// The entry may be written to vault at any time, which does not trigger a reconcile
+		return failure{reason: vaultv1alpha1.ReasonEntryMissing}
+	}
+	return failure{reason: vaultv1alpha1.ReasonFailed}
+}
+
+// requeue returns the result of a reconcile which failed with err. Permanent failures are not retried, as
+// they only go away once the VaultSecret is changed, and vault failures are retried after a fixed delay.
+// Other errors are returned, so the request is retried with the rate limited backoff.
+func requeue(log logr.Logger, err error) (ctrl.Result, error) {
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+	f := classify(err)
+	switch {
+	case f.permanent:
+		log.Info("reconcile failed permanently, waiting for the VaultSecret to change", "reason", f.reason, "error", err.Error())
+		return ctrl.Result{}, nil
+	case f.retryAfter > 0:
+		log.Info("reconcile failed, retrying", "reason", f.reason, "after", f.retryAfter, "error", err.Error())
+		return ctrl.Result{RequeueAfter: f.retryAfter}, nil
+	}
+	return ctrl.Result{}, err
+}
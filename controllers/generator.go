@@ -0,0 +1,230 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/generator"
+	"github.com/finleap-connect/vaultoperator/tracing"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+// defaultGenerators is used if the reconciler was set up without a registry.
+var defaultGenerators = generator.NewRegistry()
+
+// generateValue runs the registered generator of the given name or, if there is none, the generator
+// defined by the VaultSecretGenerator resource of that name.
+func (r *VaultSecretReconciler) generateValue(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (_ *generator.Value, err error) {
+	ctx, span := tracer.Start(ctx, "generateValue", trace.WithAttributes(tracing.GeneratorKey.String(string(gen.Name))))
+	defer func() {
+		recordGenerator(gen.Name, err)
+		tracing.End(span, err)
+	}()
+	generators := r.Generators
+	if generators == nil {
+		generators = defaultGenerators
+	}
+	if g, ok := generators.Get(gen.Name); ok {
+		return g.Generate(ctx, gen)
+	}
+
+	custom := &vaultv1alpha1.VaultSecretGenerator{}
+	if err := r.Get(ctx, types.NamespacedName{Name: string(gen.Name)}, custom); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownGenerator, gen.Name)
+		}
+		return nil, err
+	}
+	g, err := generator.NewExternal(custom, r.GeneratorPluginDir)
+	if err != nil {
+		return nil, err
+	}
+	return g.Generate(ctx, gen)
+}
+
+// locationKey identifies a field of an entry in vault.
+type locationKey struct {
+	path  string
+	field string
+}
+
+// pendingValue is a generated value which still has to be written to vault.
+type pendingValue struct {
+	field    string
+	gen      *vaultv1alpha1.VaultSecretDataGenerator
+	value    *generator.Value
+	rotation bool
+	previous string
+}
+
+// generateValues runs the generators of all locations of the VaultSecret whose value does not exist yet
+// or whose rotation is due. The generated values are collected per path and each path is written once
+// as a single new version, which is recorded in the read cache.
+func (r *VaultSecretReconciler) generateValues(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, reads *vault.ReadCache, now time.Time) error {
+	resolved := map[locationKey]bool{}
+	pending := map[string][]*pendingValue{}
+	var paths []string
+
+	add := func(path string, p *pendingValue) {
+		if _, ok := pending[path]; !ok {
+			paths = append(paths, path)
+		}
+		pending[path] = append(pending[path], p)
+	}
+	find := func(path, field string) *pendingValue {
+		for _, p := range pending[path] {
+			if p.field == field {
+				return p
+			}
+		}
+		return nil
+	}
+
+	plan := func(data vaultv1alpha1.AnyVaultSecretData) error {
+		gen, location := data.GetGenerator(), data.GetLocation()
+		if gen == nil || location == nil {
+			return nil
+		}
+		path := strings.Trim(location.Path, "/")
+		if err := r.checkPermission(vaultSecret, path); err != nil {
+			return err
+		}
+		key := locationKey{path: path, field: location.Field}
+		if resolved[key] {
+			return nil
+		}
+		// Several data entries may refer to the same generated field
+		if find(path, location.Field) != nil {
+			return nil
+		}
+
+		value, err := reads.Get(ctx, path, location.Field, location.Version)
+		if err == vault.ErrNotFound {
+			generated, err := r.generateValue(ctx, gen)
+			if err != nil {
+				return fmt.Errorf("generation of secret value failed with: %w", err)
+			}
+			add(path, &pendingValue{field: location.Field, gen: gen, value: generated})
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// A rotation may be requested with the rotate annotation regardless of the schedule
+		due := vaultSecret.RotationRequested(path, location.Field)
+		if gen.Rotation != nil {
+			scheduled, err := isRotationDue(vaultSecret, path, location.Field, gen.Rotation, now)
+			if err != nil {
+				return fmt.Errorf("invalid rotation: %w", err)
+			}
+			due = due || scheduled
+		}
+		if due {
+			generated, err := r.generateValue(ctx, gen)
+			if err != nil {
+				return fmt.Errorf("rotation of secret value failed with: %w", err)
+			}
+			add(path, &pendingValue{field: location.Field, gen: gen, value: generated, rotation: true, previous: value})
+			return nil
+		}
+		resolved[key] = true
+		return nil
+	}
+
+	for i := range vaultSecret.Spec.Data {
+		data := &vaultSecret.Spec.Data[i]
+		if err := plan(data); err != nil {
+			return fmt.Errorf("get vault secret data from %s/%s failed with: %w", data.Location.Path, data.Location.Field, err)
+		}
+		for j := range data.Variables {
+			variable := &data.Variables[j]
+			if err := plan(variable); err != nil {
+				return fmt.Errorf("get vault secret data from %s/%s failed with: %w", variable.Location.Path, variable.Location.Field, err)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		if err := r.writeGeneratedValues(ctx, vaultSecret, path, pending[path], reads, now); err != nil {
+			return fmt.Errorf("writing generated values to %s failed with: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// writeGeneratedValues writes the generated values of a path as a single new version. Values which
+// another VaultSecret sharing the location generated or rotated concurrently are not overwritten, the
+// value of the winner is used instead.
+func (r *VaultSecretReconciler) writeGeneratedValues(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, path string, values []*pendingValue, reads *vault.ReadCache, now time.Time) error {
+	owner := fmt.Sprintf("%s/%s", vaultSecret.Namespace, vaultSecret.Name)
+	writes := make([]vault.ConditionalWrite, len(values))
+	for i, p := range values {
+		fields := p.value.Fields(p.field)
+		fields[vault.GetGeneratedByKey(p.field)] = owner
+		if p.rotation {
+			fields[vault.GetPreviousKey(p.field)] = p.previous
+			writes[i] = vault.ConditionalWrite{Condition: vault.FieldEquals(p.field, p.previous), Data: fields}
+		} else {
+			writes[i] = vault.ConditionalWrite{Condition: vault.FieldAbsent(p.field), Data: fields}
+		}
+	}
+
+	result, err := r.Vault.WriteConditional(ctx, path, writes)
+	if err != nil {
+		return err
+	}
+	stored := result.Fields
+	reads.SetLatest(path, stored)
+	// Only entries created by the operator are destroyed when the generated values are purged, otherwise
+	// only the written fields are removed
+	if result.Created {
+		vaultSecret.Status.AddGeneratedPath(path)
+	} else if !containsString(vaultSecret.Status.GeneratedPaths, path) {
+		var written []string
+		for i := range values {
+			if result.Applied[i] {
+				for field := range writes[i].Data {
+					written = append(written, field)
+				}
+			}
+		}
+		if len(written) > 0 {
+			vaultSecret.Status.AddGeneratedFields(path, written)
+		}
+	}
+	for i, p := range values {
+		if _, ok := stored[p.field]; !ok {
+			return vault.ErrNotFound
+		}
+
+		if result.Applied[i] {
+			r.Log.Info("generated value", "vaultsecret", owner, "path", path, "field", p.field, "rotation", p.rotation)
+		} else {
+			r.Log.Info("value was generated concurrently by another VaultSecret, using it", "vaultsecret", owner, "path", path, "field", p.field, "generatedBy", stored[vault.GetGeneratedByKey(p.field)])
+		}
+		if p.gen.Rotation != nil {
+			vaultSecret.Status.SetRotation(path, p.field, now)
+		}
+	}
+	return nil
+}
@@ -15,7 +15,17 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
 )
 
 func ignoreNotFound(err error) error {
@@ -34,12 +44,36 @@ func containsString(slice []string, s string) bool {
 	return false
 }
 
-func removeString(slice []string, s string) (result []string) {
-	for _, item := range slice {
-		if item == s {
-			continue
-		}
-		result = append(result, item)
+// secretHash returns a hash of the type and data of the secret, which only changes if its content does.
+func secretHash(secret *corev1.Secret) string {
+	h := sha256.New()
+	writeHashField(h, []byte(secret.Type))
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeHashField(h, []byte(key))
+		writeHashField(h, secret.Data[key])
 	}
-	return
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RedactValue returns what is shown instead of a value in previews. Only the length of the value is revealed, as
+// hashes of values with little entropy could be reversed by anyone allowed to read the preview. Placeholders of
+// values which would be generated are shown as they are.
+func RedactValue(value []byte) string {
+	if string(value) == vaultv1alpha1.GeneratedPlaceholder {
+		return vaultv1alpha1.GeneratedPlaceholder
+	}
+	return fmt.Sprintf("<redacted, %d bytes>", len(value))
+}
+
+// writeHashField writes a length prefixed value, so the concatenation of values is unambiguous.
+func writeHashField(h hash.Hash, value []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(value)))
+	h.Write(length[:])
+	h.Write(value)
 }
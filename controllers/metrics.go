@@ -0,0 +1,79 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	syncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vaultoperator_vaultsecret_sync_total",
+		Help: "Number of syncs of VaultSecrets by result.",
+	}, []string{"namespace", "name", "result"})
+	lastSyncTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vaultoperator_vaultsecret_last_success_timestamp_seconds",
+		Help: "Time of the last successful sync of VaultSecrets.",
+	}, []string{"namespace", "name"})
+	generatorInvocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vaultoperator_generator_invocations_total",
+		Help: "Number of generated values by generator and result.",
+	}, []string{"generator", "result"})
+	permissionDenials = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vaultoperator_permission_denials_total",
+		Help: "Number of vault paths denied to resources by namespace.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(syncTotal, lastSyncTimestamp, generatorInvocations, permissionDenials)
+}
+
+// recordSync records the result of syncing the VaultSecret.
+func recordSync(vaultSecret *vaultv1alpha1.VaultSecret, err error, now time.Time) {
+	if err != nil {
+		syncTotal.WithLabelValues(vaultSecret.Namespace, vaultSecret.Name, resultFailure).Inc()
+		return
+	}
+	syncTotal.WithLabelValues(vaultSecret.Namespace, vaultSecret.Name, resultSuccess).Inc()
+	lastSyncTimestamp.WithLabelValues(vaultSecret.Namespace, vaultSecret.Name).Set(float64(now.Unix()))
+}
+
+// forgetSync removes the sync metrics of the deleted VaultSecret.
+func forgetSync(vaultSecret *vaultv1alpha1.VaultSecret) {
+	for _, result := range []string{resultSuccess, resultFailure} {
+		syncTotal.DeleteLabelValues(vaultSecret.Namespace, vaultSecret.Name, result)
+	}
+	lastSyncTimestamp.DeleteLabelValues(vaultSecret.Namespace, vaultSecret.Name)
+}
+
+// recordGenerator records an invocation of the generator.
+func recordGenerator(name vaultv1alpha1.VaultSecretGeneratorName, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+	generatorInvocations.WithLabelValues(string(name), result).Inc()
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+var appsGroupVersion = schema.GroupVersion{Group: "apps", Version: "v1"}
+
+// rolloutTargets restarts the rollout targets of the VaultSecret if the content of the secret changed. Changing
+// an annotation of the pod template triggers a rolling restart of the workload.
+func (r *VaultSecretReconciler) rolloutTargets(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, hash string) error {
+	for _, target := range vaultSecret.Spec.RolloutTargets {
+		workloads, err := r.getRolloutWorkloads(ctx, vaultSecret, target)
+		if err != nil {
+			return fmt.Errorf("getting rollout targets of kind %s failed with: %w", target.Kind, err)
+		}
+		for i := range workloads {
+			if err := r.rollout(ctx, vaultSecret, &workloads[i], hash); err != nil {
+				return fmt.Errorf("restarting %s %s failed with: %w", target.Kind, workloads[i].GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// getRolloutWorkloads returns the workloads referenced by the rollout target. Workloads are read as unstructured
+// objects, so they are not cached by the manager.
+func (r *VaultSecretReconciler) getRolloutWorkloads(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, target vaultv1alpha1.VaultSecretRolloutTarget) ([]unstructured.Unstructured, error) {
+	gvk := appsGroupVersion.WithKind(string(target.Kind))
+	if target.Name != "" {
+		workload := unstructured.Unstructured{}
+		workload.SetGroupVersionKind(gvk)
+		if err := r.Get(ctx, types.NamespacedName{Namespace: vaultSecret.Namespace, Name: target.Name}, &workload); err != nil {
+			if ignoreNotFound(err) == nil {
+				r.Log.Info("rollout target does not exist", "vaultsecret", client.ObjectKeyFromObject(vaultSecret), "kind", target.Kind, "name", target.Name)
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []unstructured.Unstructured{workload}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(target.Selector)
+	if err != nil {
+		return nil, err
+	}
+	workloads := unstructured.UnstructuredList{}
+	workloads.SetGroupVersionKind(gvk.GroupVersion().WithKind(string(target.Kind) + "List"))
+	if err := r.List(ctx, &workloads, client.InNamespace(vaultSecret.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	return workloads.Items, nil
+}
+
+// rolloutAnnotation returns the annotation recording the hash of the secret of the VaultSecret on its rollout
+// targets. Each VaultSecret has its own annotation, so a workload can be the target of several VaultSecrets.
+func rolloutAnnotation(vaultSecret *vaultv1alpha1.VaultSecret) string {
+	name := "secret-hash." + vaultSecret.Name
+	// The name of an annotation is limited to 63 characters
+	if len(name) > validation.LabelValueMaxLength {
+		sum := sha256.Sum256([]byte(vaultSecret.Name))
+		name = name[:validation.LabelValueMaxLength-9] + "-" + hex.EncodeToString(sum[:4])
+	}
+	return "vault.finleap.cloud/" + name
+}
+
+// rollout restarts the workload if the hash of the secret differs from the hash recorded in the annotation of
+// the workload, by setting the annotation on its pod template as well. If no hash was recorded yet, because the
+// workload just became a rollout target, the hash is only recorded and the workload is not restarted.
+func (r *VaultSecretReconciler) rollout(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, workload *unstructured.Unstructured, hash string) error {
+	key := rolloutAnnotation(vaultSecret)
+	recorded, ok := workload.GetAnnotations()[key]
+	if ok && recorded == hash {
+		return nil
+	}
+	annotations := map[string]string{key: hash}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	}
+	if ok {
+		patch["spec"] = map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": annotations},
+			},
+		}
+	}
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if err := r.Patch(ctx, workload, client.RawPatch(types.MergePatchType, raw)); err != nil {
+		return err
+	}
+	if !ok {
+		r.Log.V(1).Info("recorded secret hash on rollout target", "vaultsecret", client.ObjectKeyFromObject(vaultSecret), "kind", workload.GetKind(), "name", workload.GetName())
+		return nil
+	}
+	r.Log.Info("restarted rollout target", "vaultsecret", client.ObjectKeyFromObject(vaultSecret), "kind", workload.GetKind(), "name", workload.GetName())
+	r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", fmt.Sprintf("Restarted %s %s", workload.GetKind(), workload.GetName()))
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"strings"
+	"time"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+const previousKeySuffix = "_previous"
+
+// isRotationDue checks whether the generated value at the given location has to be rotated. Values
+// which were not generated by this VaultSecret start their rotation schedule now.
+func isRotationDue(vaultSecret *vaultv1alpha1.VaultSecret, path, field string, rotation *vaultv1alpha1.VaultSecretGeneratorRotation, now time.Time) (bool, error) {
+	state := vaultSecret.Status.GetRotation(path, field)
+	if state == nil {
+		vaultSecret.Status.SetRotation(path, field, now)
+		return false, nil
+	}
+	next, err := rotation.NextRotation(state.LastRotated.Time)
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(next), nil
+}
+
+// getPreviousValue returns the value replaced by the last rotation as long as its grace period did not expire.
+func (r *VaultSecretReconciler) getPreviousValue(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, data *vaultv1alpha1.VaultSecretData, reads *vault.ReadCache, now time.Time) (string, bool, error) {
+	if data.Location == nil || data.Generator == nil || data.Generator.Rotation == nil || data.Generator.Rotation.GracePeriod == nil {
+		return "", false, nil
+	}
+	path := strings.Trim(data.Location.Path, "/")
+	state := vaultSecret.Status.GetRotation(path, data.Location.Field)
+	if state == nil || !now.Before(state.LastRotated.Add(data.Generator.Rotation.GracePeriod.Duration)) {
+		return "", false, nil
+	}
+	fields, err := reads.GetAll(ctx, path, 0)
+	if err == vault.ErrNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	value, ok := fields[vault.GetPreviousKey(data.Location.Field)]
+	if !ok {
+		return "", false, nil
+	}
+	if isBinary(data.Location, fields) {
+		byteVal, err := b64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", false, err
+		}
+		value = string(byteVal)
+	}
+	return value, true, nil
+}
+
+// nextRotation returns the duration until the next rotation or grace period expiry of any generated
+// value of the VaultSecret is due. It returns zero if nothing is scheduled.
+func nextRotation(vaultSecret *vaultv1alpha1.VaultSecret, now time.Time) time.Duration {
+	var next time.Duration
+	schedule := func(data vaultv1alpha1.AnyVaultSecretData) {
+		gen, location := data.GetGenerator(), data.GetLocation()
+		if gen == nil || gen.Rotation == nil || location == nil {
+			return
+		}
+		state := vaultSecret.Status.GetRotation(strings.Trim(location.Path, "/"), location.Field)
+		if state == nil {
+			return
+		}
+		candidates := []time.Time{}
+		if t, err := gen.Rotation.NextRotation(state.LastRotated.Time); err == nil {
+			candidates = append(candidates, t)
+		}
+		if gen.Rotation.GracePeriod != nil {
+			candidates = append(candidates, state.LastRotated.Add(gen.Rotation.GracePeriod.Duration))
+		}
+		for _, t := range candidates {
+			if d := t.Sub(now); d > 0 && (next == 0 || d < next) {
+				next = d
+			}
+		}
+	}
+	for i := range vaultSecret.Spec.Data {
+		data := &vaultSecret.Spec.Data[i]
+		schedule(data)
+		for j := range data.Variables {
+			schedule(&data.Variables[j])
+		}
+	}
+	return next
+}
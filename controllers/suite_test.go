@@ -60,6 +60,7 @@ var (
 	testNameCounter = 0 // Used for predictable test names
 	// Instances of reconcilers to test against
 	testVSR            *VaultSecretReconciler
+	testVSIR           *VaultSecretImportReconciler
 	testWithEnterprise bool = false
 )
 
@@ -151,6 +152,13 @@ var _ = BeforeSuite(func() {
 		Recorder: &record.FakeRecorder{}, // dummy recorder
 		Vault:    testVaultClient,
 	}
+	testVSIR = &VaultSecretImportReconciler{
+		Client:   k8sClient,
+		Scheme:   scheme.Scheme,
+		Log:      logf.Log.WithName("controllers").WithName("VaultSecretImport"),
+		Recorder: &record.FakeRecorder{}, // dummy recorder
+		Vault:    testVaultClient,
+	}
 
 	// err = (testVSR).SetupWithManager(k8sManager)
 	// Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,53 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/finleap-connect/vaultoperator/tracing"
+)
+
+var tracer = tracing.Tracer("controllers")
+
+const (
+	// secretNameKey is the name of the rendered secret.
+	secretNameKey = attribute.Key("secret.name")
+	// secretKeyKey is the key of the secret data a template renders.
+	secretKeyKey = attribute.Key("secret.key")
+)
+
+// executeTemplate renders the template of the secret key with the values of its variables.
+func executeTemplate(ctx context.Context, key, text string, variables map[string]string) (_ string, err error) {
+	_, span := tracer.Start(ctx, "executeTemplate", trace.WithAttributes(secretKeyKey.String(key)))
+	defer func() { tracing.End(span, err) }()
+
+	tmpl, err := template.New("template").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: parsing failed with: %v", ErrInvalidTemplate, err)
+	}
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, variables); err != nil {
+		return "", fmt.Errorf("%w: execution failed with: %v", ErrInvalidTemplate, err)
+	}
+	return output.String(), nil
+}
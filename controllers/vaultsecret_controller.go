@@ -15,28 +15,31 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
-	"text/template"
+	"time"
 
-	"github.com/Masterminds/sprig/v3"
 	"github.com/go-logr/logr"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ref "k8s.io/client-go/tools/reference"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
-	"github.com/finleap-connect/vaultoperator/util"
+	"github.com/finleap-connect/vaultoperator/generator"
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/tracing"
 	"github.com/finleap-connect/vaultoperator/vault"
 
 	b64 "encoding/base64"
@@ -45,8 +48,12 @@ import (
 )
 
 const (
-	finalizerName  = "vault.finleap.cloud"
-	generator_uuid = "uuid"
+	finalizerName = "vault.finleap.cloud"
+	// secretHashAnnotation is set on secrets to the hash of their type and data, so changes of the content
+	// can be detected by other tools.
+	secretHashAnnotation = "vault.finleap.cloud/secret-hash"
+	// fieldOwner is the field manager of the fields of secrets applied by the operator.
+	fieldOwner = client.FieldOwner("vault-operator")
 )
 
 // VaultSecretReconciler reconciles a VaultSecret object
@@ -56,14 +63,22 @@ type VaultSecretReconciler struct {
 	Recorder record.EventRecorder
 	Vault    *vault.Client
 	Scheme   *runtime.Scheme
+	// Generators available by name, defaults to the built-in generators.
+	Generators *generator.Registry
+	// Directory containing the executables of exec generators, which are disabled if empty.
+	GeneratorPluginDir string
 }
 
 // +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecrets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecretgenerators,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;patch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update
-func (r *VaultSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *VaultSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	ctx, span := tracer.Start(ctx, "VaultSecret.Reconcile", trace.WithAttributes(tracing.NameKey.String(req.Name), tracing.NamespaceKey.String(req.Namespace)))
+	defer func() { tracing.End(span, err) }()
 	log := r.Log.WithValues("vaultsecret", req.NamespacedName)
 	log.Info("Started reconciliation...")
 
@@ -79,7 +94,7 @@ func (r *VaultSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// Check whether object is being deleted
 	if deleted, err := r.handleDeletion(ctx, log, vaultSecret); deleted || err != nil {
-		return ctrl.Result{}, err
+		return requeue(log, err)
 	}
 
 	// Validate VaultSecret
@@ -96,76 +111,188 @@ func (r *VaultSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Only render a preview of the secret in dry-run mode
+	if vaultSecret.IsDryRun() {
+		return requeue(log, r.handleDryRun(ctx, log, vaultSecret, secretReq))
+	}
+
+	// Read all entries from vault again if a sync was forced
+	if _, ok := vaultSecret.Annotations[vaultv1alpha1.ForceSyncAnnotation]; ok {
+		log.Info("sync was forced, invalidating cached reads")
+		r.invalidateCache(vaultSecret)
+	}
+
 	// VaultSecret was either created or updated, create or update secret accordingly
-	if err := r.handleCreateOrUpdate(ctx, log, vaultSecret, secretReq); err != nil {
+	err = r.handleCreateOrUpdate(ctx, log, vaultSecret, secretReq)
+	recordSync(vaultSecret, err, time.Now())
+	if err != nil {
+		return requeue(log, err)
+	}
+
+	// Requests of a forced sync or rotation were handled
+	if err := r.removeAnnotations(ctx, vaultSecret, vaultv1alpha1.ForceSyncAnnotation, vaultv1alpha1.RotateAnnotation); err != nil {
+		log.Error(err, "removing handled annotations failed")
 		return ctrl.Result{}, err
 	}
 
+	// Come back as soon as the next generated value is due for rotation
+	if next := nextRotation(vaultSecret, time.Now()); next > 0 {
+		log.Info("scheduled rotation of generated values", "after", next)
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
 func (r *VaultSecretReconciler) handleCreateOrUpdate(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret, n types.NamespacedName) error {
-	secret := corev1.Secret{}
-	status := vaultSecret.Status
+	// Keep the current state to only write the status if it changed
+	existingVaultSecret := vaultSecret.DeepCopy()
+	policy := vaultSecret.Spec.GetCreationPolicy()
+	// The preview of a previous dry run is outdated once the secret is written
+	vaultSecret.Status.Preview = nil
 
-	// VaultSecret has a secret ref, get the secret
-	if status.SecretObject != nil {
-		if err := r.Get(ctx, n, &secret); err != nil {
-			if ignoreNotFound(err) != nil {
-				r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Checking owned secret failed with: %v", err))
-				return err
-			} else if err != nil {
-				// Not found so let's reset the reference and let's re-create it
-				status.SecretObject = nil
-			}
-		}
+	existingSecret := &corev1.Secret{}
+	if err := r.Get(ctx, n, existingSecret); ignoreNotFound(err) != nil {
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Checking owned secret failed with: %v", err))
+		return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonFailed, err)
+	} else if err != nil {
+		existingSecret = nil
 	}
 
-	if status.SecretObject == nil { // Checking here as above control flow can reset secret
-		secret.ObjectMeta.Name = n.Name
-		secret.ObjectMeta.Namespace = n.Namespace
+	switch {
+	case policy == vaultv1alpha1.MergeCreationPolicy && existingSecret == nil:
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Secret %s to merge into does not exist", n.Name))
+		return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonSecretMissing, fmt.Errorf("%w: %s", ErrSecretMissing, n.Name))
+	case policy == vaultv1alpha1.OwnerCreationPolicy || policy == vaultv1alpha1.OrphanCreationPolicy:
+		// Secrets which were not created by the operator are not taken over
+		if existingSecret != nil && !metav1.IsControlledBy(existingSecret, vaultSecret) && !isAdoptable(existingSecret) {
+			err := apierrors.NewAlreadyExists(corev1.Resource("secrets"), n.Name)
+			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("creating secret failed with: %v", err))
+			return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonSecretExists, err)
+		}
 	}
 
-	err := controllerutil.SetControllerReference(vaultSecret, &secret, r.Scheme)
-	if err != nil {
-		return err
+	// Only the fields set here are applied and owned by the operator, others set on the secret are kept
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      n.Name,
+			Namespace: n.Namespace,
+		},
+	}
+	if policy == vaultv1alpha1.OwnerCreationPolicy {
+		if err := controllerutil.SetControllerReference(vaultSecret, secret, r.Scheme); err != nil {
+			return err
+		}
 	}
 
-	r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Building required state of secret")
-	// TODO: we should check spec against status to check if update is necessary
-	if err := r.updateSecret(&secret, vaultSecret); err != nil {
+	if err := r.updateSecret(ctx, secret, vaultSecret); err != nil {
 		log.Error(err, "failed to update secret")
-		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Failed to update secret: %v", err))
-		return err // TODO: maybe we should wrap returned errors
+		reason := classify(err).reason
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, reason, fmt.Sprintf("Failed to update secret: %v", err))
+		return r.failed(ctx, existingVaultSecret, vaultSecret, reason, err)
 	}
 
-	// Update or create the secret with the up-to-date data
-	if status.SecretObject != nil {
-		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Updating secret")
-		if err := r.Update(ctx, &secret); err != nil {
-			log.Error(err, "failed to create or update secret")
-			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("updating secret failed with: %v", err))
-			return err
-		}
-	} else {
-		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Creating secret")
-		if err := r.Create(ctx, &secret); err != nil {
-			log.Error(err, "failed to create secret")
-			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("creating secret failed with: %v", err))
-			return err
+	if policy == vaultv1alpha1.NoneCreationPolicy {
+		log.V(1).Info("secret is not managed")
+		if err := r.cleanupPreviousSecret(ctx, log, vaultSecret, nil); err != nil {
+			return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonFailed, err)
 		}
+		vaultSecret.SetReadyCondition(metav1.ConditionTrue, vaultv1alpha1.ReasonSecretNotManaged, "Generated values are written to vault, no secret is managed")
+		return r.updateStatus(ctx, log, existingVaultSecret, vaultSecret)
+	}
+	if policy == vaultv1alpha1.MergeCreationPolicy {
+		// The type of a secret can not be changed, so the type of the existing one is kept
+		secret.Type = ""
+	}
+	metav1.SetMetaDataAnnotation(&secret.ObjectMeta, secretHashAnnotation, secretHash(secret))
+
+	// Apply the up-to-date data, the API server does not write the secret if nothing changed
+	if err := r.Patch(ctx, secret, client.Apply, fieldOwner, client.ForceOwnership); err != nil {
+		log.Error(err, "failed to apply secret")
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("applying secret failed with: %v", err))
+		return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonFailed, err)
+	}
+	switch {
+	case existingSecret == nil:
+		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Created secret")
+		vaultSecret.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
+	case existingSecret.ResourceVersion != secret.ResourceVersion:
+		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Updated secret")
+		vaultSecret.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
+	default:
+		log.V(1).Info("secret is up to date")
 	}
 
-	r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", "Updating vaultSecret")
 	// Save the reference to make sure secret is cleaned up later as well
-	secretRef, err := ref.GetReference(r.Scheme, &secret)
+	secretRef, err := ref.GetReference(r.Scheme, secret)
 	if err != nil {
 		log.Error(err, "unable to get reference")
 		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", "Failed fetching reference to related secret")
 		return err
 	}
-	vaultSecret.Status.SecretObject = secretRef
-	if err := r.Update(ctx, vaultSecret); err != nil {
+	// The new secret is in place, so the previous one can be cleaned up if the name changed
+	if err := r.cleanupPreviousSecret(ctx, log, vaultSecret, secretRef); err != nil {
+		return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonFailed, err)
+	}
+	if err := r.rolloutTargets(ctx, vaultSecret, secret.Annotations[secretHashAnnotation]); err != nil {
+		log.Error(err, "rollout failed")
+		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Rollout failed: %v", err))
+		return r.failed(ctx, existingVaultSecret, vaultSecret, vaultv1alpha1.ReasonRolloutFailed, err)
+	}
+	vaultSecret.SetReadyCondition(metav1.ConditionTrue, vaultv1alpha1.ReasonSynced, fmt.Sprintf("Secret %s is in sync with vault", n.Name))
+	return r.updateStatus(ctx, log, existingVaultSecret, vaultSecret)
+}
+
+// invalidateCache drops the cached reads of all entries the VaultSecret refers to.
+func (r *VaultSecretReconciler) invalidateCache(vaultSecret *vaultv1alpha1.VaultSecret) {
+	invalidate := func(data vaultv1alpha1.AnyVaultSecretData) {
+		if location := data.GetLocation(); location != nil {
+			r.Vault.InvalidateCache(location.Path)
+		}
+	}
+	for i := range vaultSecret.Spec.Data {
+		invalidate(&vaultSecret.Spec.Data[i])
+		for j := range vaultSecret.Spec.Data[i].Variables {
+			invalidate(&vaultSecret.Spec.Data[i].Variables[j])
+		}
+	}
+	for i := range vaultSecret.Spec.DataFrom {
+		invalidate(&vaultSecret.Spec.DataFrom[i])
+	}
+}
+
+// removeAnnotations removes the annotations from the VaultSecret if it has any of them.
+func (r *VaultSecretReconciler) removeAnnotations(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, keys ...string) error {
+	base := vaultSecret.DeepCopy()
+	for _, key := range keys {
+		delete(vaultSecret.Annotations, key)
+	}
+	if len(base.Annotations) == len(vaultSecret.Annotations) {
+		return nil
+	}
+	return r.Patch(ctx, vaultSecret, client.MergeFrom(base))
+}
+
+// isAdoptable returns true if the secret was created by the operator but is not owned by any VaultSecret,
+// e.g. because it was created with the creation policy Orphan.
+func isAdoptable(secret *corev1.Secret) bool {
+	_, ok := secret.Annotations[secretHashAnnotation]
+	return ok && metav1.GetControllerOf(secret) == nil
+}
+
+// failed records the error in the Ready condition of the VaultSecret and returns it.
+func (r *VaultSecretReconciler) failed(ctx context.Context, base, vaultSecret *vaultv1alpha1.VaultSecret, reason string, err error) error {
+	vaultSecret.SetReadyCondition(metav1.ConditionFalse, reason, err.Error())
+	if err := r.patchStatus(ctx, base, vaultSecret); err != nil {
+		r.Log.Error(err, "status update failed", "vaultsecret", client.ObjectKeyFromObject(vaultSecret))
+	}
+	return err
+}
+
+// updateStatus writes the status of the VaultSecret if it changed and reports failures.
+func (r *VaultSecretReconciler) updateStatus(ctx context.Context, log logr.Logger, base, vaultSecret *vaultv1alpha1.VaultSecret) error {
+	if err := r.patchStatus(ctx, base, vaultSecret); err != nil {
 		log.Error(err, "status update failed")
 		r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", "Failed to update vaultSecret")
 		return err
@@ -173,6 +300,31 @@ func (r *VaultSecretReconciler) handleCreateOrUpdate(ctx context.Context, log lo
 	return nil
 }
 
+// patchStatus writes the status of the VaultSecret if it differs from the status of base. Only the changed
+// fields are sent, so concurrent changes of the object do not conflict.
+func (r *VaultSecretReconciler) patchStatus(ctx context.Context, base, vaultSecret *vaultv1alpha1.VaultSecret) error {
+	if equality.Semantic.DeepEqual(base.Status, vaultSecret.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, vaultSecret, client.MergeFrom(base))
+}
+
+// patchFinalizers changes the finalizers of the VaultSecret. The patch is only applied to the version of
+// the object it is based on, on conflicts the object is fetched again and the change is retried.
+func (r *VaultSecretReconciler) patchFinalizers(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, mutate func(*vaultv1alpha1.VaultSecret)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		base := vaultSecret.DeepCopy()
+		mutate(vaultSecret)
+		err := r.Patch(ctx, vaultSecret, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if apierrors.IsConflict(err) {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(vaultSecret), vaultSecret); err != nil {
+				return err
+			}
+		}
+		return err
+	})
+}
+
 func (r *VaultSecretReconciler) handleValidation(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret) error {
 	if err := vaultSecret.ValidateCreate(); err != nil {
 		log.Error(err, "validation failed")
@@ -191,8 +343,9 @@ func (r *VaultSecretReconciler) handleDeletion(ctx context.Context, log logr.Log
 		// then lets add the finalizer and update the object. This is equivalent
 		// registering our finalizer.
 		if !containsString(vaultSecret.ObjectMeta.Finalizers, finalizerName) {
-			vaultSecret.ObjectMeta.Finalizers = append(vaultSecret.ObjectMeta.Finalizers, finalizerName)
-			if err := r.Update(context.Background(), vaultSecret); err != nil {
+			if err := r.patchFinalizers(ctx, vaultSecret, func(vs *vaultv1alpha1.VaultSecret) {
+				controllerutil.AddFinalizer(vs, finalizerName)
+			}); err != nil {
 				return false, err
 			}
 			log.Info("finalizer added")
@@ -210,14 +363,16 @@ func (r *VaultSecretReconciler) handleDeletion(ctx context.Context, log logr.Log
 			}
 
 			// Remove our finalizer from the list and update it.
-			vaultSecret.ObjectMeta.Finalizers = removeString(vaultSecret.ObjectMeta.Finalizers, finalizerName)
-			if err := r.Update(context.Background(), vaultSecret); err != nil {
+			if err := r.patchFinalizers(ctx, vaultSecret, func(vs *vaultv1alpha1.VaultSecret) {
+				controllerutil.RemoveFinalizer(vs, finalizerName)
+			}); ignoreNotFound(err) != nil {
 				log.Error(err, "removing finalizer failed")
 				r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", "Removing finalizer failed")
 				return false, err
 			}
 
 			log.Info("finalizer removed")
+			forgetSync(vaultSecret)
 
 			return true, nil
 		}
@@ -227,30 +382,180 @@ func (r *VaultSecretReconciler) handleDeletion(ctx context.Context, log logr.Log
 
 func (r *VaultSecretReconciler) deleteExternalResources(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret) error {
 	status := vaultSecret.Status
-	if status.SecretObject != nil {
-		if err := r.Delete(ctx, &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: status.SecretObject.Namespace,
-				Name:      status.SecretObject.Name,
-			},
-		}); client.IgnoreNotFound(err) != nil {
-			log.Error(err, "failed to remove owned secret")
+	for _, secretRef := range []*corev1.ObjectReference{status.SecretObject, status.PreviousSecretObject} {
+		if secretRef == nil {
+			continue
+		}
+		if err := r.cleanupSecret(ctx, vaultSecret, secretRef); err != nil {
+			log.Error(err, "failed to remove owned secret", "secret", secretRef.Name)
 			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", "Failed to remove owned secret")
 			return err
-		} else {
-			status.SecretObject = nil
+		}
+	}
+
+	if vaultSecret.Spec.PurgeGenerated {
+		if err := r.purgeGenerated(ctx, log, vaultSecret); err != nil {
+			log.Error(err, "failed to purge generated values")
+			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Failed to purge generated values: %v", err))
+			return err
 		}
 	}
 	return nil
 }
 
-func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret *vaultv1alpha1.VaultSecret) error {
+// cleanupSecret deletes the referenced secret if it is owned by the VaultSecret or, with the deletion policy
+// Retain, removes the owner reference so it is not garbage collected. Other secrets are left alone.
+func (r *VaultSecretReconciler) cleanupSecret(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, secretRef *corev1.ObjectReference) error {
+	key := types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, key, secret); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(secret, vaultSecret) {
+			return nil
+		}
+		if vaultSecret.Spec.GetDeletionPolicy() != vaultv1alpha1.RetainDeletionPolicy {
+			return client.IgnoreNotFound(r.Delete(ctx, secret, client.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion}))
+		}
+
+		base := secret.DeepCopy()
+		var owners []metav1.OwnerReference
+		for _, owner := range secret.OwnerReferences {
+			if owner.UID != vaultSecret.UID {
+				owners = append(owners, owner)
+			}
+		}
+		secret.OwnerReferences = owners
+		return r.Patch(ctx, secret, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// cleanupPreviousSecret cleans up the secret which was managed before the secret name changed. The previous
+// secret is recorded in the status until it was cleaned up, so it is not lost if that fails.
+func (r *VaultSecretReconciler) cleanupPreviousSecret(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret, current *corev1.ObjectReference) error {
+	status := &vaultSecret.Status
+	if active := status.SecretObject; active != nil && !sameSecret(active, current) {
+		status.PreviousSecretObject = active
+	}
+	status.SecretObject = current
+
+	previous := status.PreviousSecretObject
+	if previous == nil {
+		return nil
+	}
+	// The name may have been changed back before the previous secret was cleaned up
+	if !sameSecret(previous, current) {
+		if err := r.cleanupSecret(ctx, vaultSecret, previous); err != nil {
+			log.Error(err, "failed to clean up previous secret", "secret", previous.Name)
+			r.Recorder.Event(vaultSecret, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Failed to clean up previous secret %s: %v", previous.Name, err))
+			return err
+		}
+		log.Info("cleaned up previous secret", "secret", previous.Name)
+		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", fmt.Sprintf("Cleaned up previous secret %s", previous.Name))
+	}
+	status.PreviousSecretObject = nil
+	return nil
+}
+
+func sameSecret(a, b *corev1.ObjectReference) bool {
+	return a != nil && b != nil && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// purgeGenerated destroys the entries in vault which were created for values generated for the VaultSecret
+// and removes the fields with generated values from other entries, unless another VaultSecret still refers
+// to them.
+func (r *VaultSecretReconciler) purgeGenerated(ctx context.Context, log logr.Logger, vaultSecret *vaultv1alpha1.VaultSecret) error {
+	status := vaultSecret.Status
+	if len(status.GeneratedPaths) == 0 && len(status.GeneratedFields) == 0 {
+		return nil
+	}
+	others := &vaultv1alpha1.VaultSecretList{}
+	if err := r.List(ctx, others); err != nil {
+		return err
+	}
+	keep := func(path string) (bool, error) {
+		if err := r.checkPermission(vaultSecret, path); err != nil {
+			return false, err
+		}
+		if by := referencedBy(others.Items, vaultSecret, path); by != nil {
+			log.Info("keeping generated values referenced by another VaultSecret", "path", path, "referencedBy", client.ObjectKeyFromObject(by))
+			return true, nil
+		}
+		return false, nil
+	}
+
+	for _, path := range status.GeneratedPaths {
+		kept, err := keep(path)
+		if err != nil {
+			return err
+		} else if kept {
+			continue
+		}
+		if err := r.Vault.Destroy(ctx, path); err != nil {
+			return fmt.Errorf("destroying %s failed with: %w", path, err)
+		}
+		log.Info("purged generated values", "path", path)
+		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", fmt.Sprintf("Purged generated values at %s", path))
+	}
+	for _, generated := range status.GeneratedFields {
+		kept, err := keep(generated.Path)
+		if err != nil {
+			return err
+		} else if kept {
+			continue
+		}
+		if err := r.Vault.DeleteFields(ctx, generated.Path, generated.Fields); err != nil {
+			return fmt.Errorf("removing generated fields from %s failed with: %w", generated.Path, err)
+		}
+		log.Info("purged generated fields", "path", generated.Path, "fields", generated.Fields)
+		r.Recorder.Event(vaultSecret, corev1.EventTypeNormal, "Info", fmt.Sprintf("Purged generated fields %s at %s", strings.Join(generated.Fields, ", "), generated.Path))
+	}
+	return nil
+}
+
+// referencedBy returns a VaultSecret other than the given one which refers to the vault path and is not
+// being deleted, nil if there is none.
+func referencedBy(vaultSecrets []vaultv1alpha1.VaultSecret, vaultSecret *vaultv1alpha1.VaultSecret, path string) *vaultv1alpha1.VaultSecret {
+	for i := range vaultSecrets {
+		other := &vaultSecrets[i]
+		if other.UID == vaultSecret.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if other.ReferencesPath(path) {
+			return other
+		}
+	}
+	return nil
+}
+
+// updateSecret generates missing values, writes them to vault and renders the secret.
+func (r *VaultSecretReconciler) updateSecret(ctx context.Context, secret *corev1.Secret, vaultSecret *vaultv1alpha1.VaultSecret) (err error) {
+	ctx, span := tracer.Start(ctx, "updateSecret", trace.WithAttributes(secretNameKey.String(secret.Name)))
+	defer func() { tracing.End(span, err) }()
+
+	// Entries are only read once per reconcile, even if they are referenced several times
+	reads := r.Vault.NewReadCache()
+
+	// Generate missing values and write them to vault, once per path
+	now := time.Now()
+	if err := r.generateValues(ctx, vaultSecret, reads, now); err != nil {
+		return err
+	}
+	return r.renderSecret(ctx, secret, vaultSecret, reads, now)
+}
+
+// renderSecret sets the type, labels and data of the secret from the VaultSecret and the entries in vault.
+func (r *VaultSecretReconciler) renderSecret(ctx context.Context, secret *corev1.Secret, vaultSecret *vaultv1alpha1.VaultSecret, reads *vault.ReadCache, now time.Time) error {
 	switch {
 	case vaultSecret.Spec.SecretType != "":
 		secret.Type = vaultSecret.Spec.SecretType
 	// Check if it is a pull secret, if so set type
 	case len(vaultSecret.Spec.Data) == 1 && vaultSecret.Spec.Data[0].Name == corev1.DockerConfigJsonKey:
 		secret.Type = corev1.SecretTypeDockerConfigJson
+	// Set the default of the API server, so the secret does not appear changed once it was created
+	case secret.Type == "":
+		secret.Type = corev1.SecretTypeOpaque
 	}
 
 	// Update secret labels
@@ -269,7 +574,7 @@ func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret
 			var value string
 			if data.Location != nil { // Location was provided
 				var err error
-				value, err = r.getVaultSecretData(vaultSecret, &data)
+				value, err = r.getVaultSecretData(ctx, vaultSecret, &data, reads)
 				if err != nil {
 					return fmt.Errorf("get vault secret data from %s/%s failed with: %w", data.Location.Path, data.Location.Field, err)
 				}
@@ -277,22 +582,17 @@ func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret
 				// Gather all variable values
 				variables := map[string]string{}
 				for _, variable := range data.Variables {
-					variableValue, err := r.getVaultSecretData(vaultSecret, &variable)
+					variableValue, err := r.getVaultSecretData(ctx, vaultSecret, &variable, reads)
 					if err != nil {
 						return fmt.Errorf("get vault secret data from %s/%s failed with: %w", variable.Location.Path, variable.Location.Field, err)
 					}
 					variables[variable.Name] = variableValue
 				}
 				// Run templating
-				tmpl, err := template.New("template").Funcs(sprig.TxtFuncMap()).Parse(data.Template)
-				if err != nil {
-					return fmt.Errorf("template parsing failed with: %w", err)
-				}
-				var output bytes.Buffer
-				if err := tmpl.Execute(&output, variables); err != nil {
-					return fmt.Errorf("template execute failed with: %w", err)
+				var err error
+				if value, err = executeTemplate(ctx, data.Name, data.Template, variables); err != nil {
+					return err
 				}
-				value = output.String()
 			} else {
 				return errors.New("vaultsecret malformed either location or template+variables required")
 			}
@@ -300,6 +600,15 @@ func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret
 				secret.Data = map[string][]byte{}
 			}
 			secret.Data[data.Name] = []byte(value)
+
+			// Keep the value replaced by a rotation available during its grace period
+			if previous, ok, err := r.getPreviousValue(ctx, vaultSecret, &data, reads, now); err != nil {
+				return fmt.Errorf("get previous vault secret data from %s/%s failed with: %w", data.Location.Path, data.Location.Field, err)
+			} else if ok {
+				secret.Data[data.Name+previousKeySuffix] = []byte(previous)
+			} else if data.Generator != nil && data.Generator.Rotation != nil {
+				delete(secret.Data, data.Name+previousKeySuffix)
+			}
 		}
 	}
 
@@ -307,7 +616,7 @@ func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret
 		otherVaultData := make(map[string]bool)
 
 		for _, data := range vaultSecret.Spec.DataFrom {
-			if pairs, err := r.getVaultSecretDataFrom(vaultSecret, &data); err != nil {
+			if pairs, err := r.getVaultSecretDataFrom(ctx, vaultSecret, &data, reads); err != nil {
 				return fmt.Errorf("get vault secret data from %s failed with: %w", data.Path, err)
 			} else {
 				if secret.Data == nil {
@@ -335,7 +644,7 @@ func (r *VaultSecretReconciler) updateSecret(secret *corev1.Secret, vaultSecret
 	return nil
 }
 
-func (r *VaultSecretReconciler) getVaultSecretDataFrom(vaultSecret *vaultv1alpha1.VaultSecret, data vaultv1alpha1.AnyVaultSecretData) (map[string]string, error) {
+func (r *VaultSecretReconciler) getVaultSecretDataFrom(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, data vaultv1alpha1.AnyVaultSecretData, reads *vault.ReadCache) (map[string]string, error) {
 	if data.GetLocation() == nil {
 		return nil, errors.New("location missing")
 	}
@@ -345,7 +654,7 @@ func (r *VaultSecretReconciler) getVaultSecretDataFrom(vaultSecret *vaultv1alpha
 		return nil, err
 	}
 
-	if fields, err := r.Vault.GetAll(path, location.Version); err == vault.ErrNotFound && data.GetGenerator() != nil {
+	if fields, err := reads.GetAll(ctx, path, location.Version); err == vault.ErrNotFound && data.GetGenerator() != nil {
 		return nil, fmt.Errorf("generation of secret value failed with: %w", err)
 	} else {
 		resultingSecrets := make(map[string]string)
@@ -361,40 +670,33 @@ func (r *VaultSecretReconciler) getVaultSecretDataFrom(vaultSecret *vaultv1alpha
 			}
 		}
 
-		return fields, err
+		return resultingSecrets, err
 	}
 }
 
-func (r *VaultSecretReconciler) getVaultSecretData(vaultSecret *vaultv1alpha1.VaultSecret, data vaultv1alpha1.AnyVaultSecretData) (string, error) {
+func (r *VaultSecretReconciler) getVaultSecretData(ctx context.Context, vaultSecret *vaultv1alpha1.VaultSecret, data vaultv1alpha1.AnyVaultSecretData, reads *vault.ReadCache) (_ string, err error) {
 	if data.GetLocation() == nil {
 		return "", errors.New("location missing")
 	}
 	location := data.GetLocation()
 	path := strings.Trim(location.Path, "/")
-	err := r.checkPermission(vaultSecret, path)
-	if err != nil {
+	ctx, span := tracer.Start(ctx, "getVaultSecretData", trace.WithAttributes(
+		tracing.PathKey.String(path), tracing.FieldKey.String(location.Field), tracing.VersionKey.Int(location.Version),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.checkPermission(vaultSecret, path); err != nil {
 		return "", err
 	}
-	value, err := r.Vault.Get(path, location.Field, location.Version)
-	var isBinary bool
-	if err == vault.ErrNotFound && data.GetGenerator() != nil {
-		value, isBinary, err = r.generateValue(data.GetGenerator())
-		if err != nil {
-			return "", fmt.Errorf("generation of secret value failed with: %w", err)
-		}
-		location.IsBinary = isBinary
-		fields := map[string]interface{}{
-			location.Field: value,
-		}
-		if location.IsBinary {
-			fields[vault.GetIsBinaryKey(location.Field)] = "1"
-		}
-		err = r.Vault.CreateOrUpdate(path, fields)
-	}
+	fields, err := reads.GetAll(ctx, path, location.Version)
 	if err != nil {
 		return "", err
 	}
-	if location.IsBinary {
+	value, ok := fields[location.Field]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	if isBinary(location, fields) {
 		byteVal, err := b64.StdEncoding.DecodeString(value)
 		if err != nil {
 			return "", err
@@ -404,89 +706,13 @@ func (r *VaultSecretReconciler) getVaultSecretData(vaultSecret *vaultv1alpha1.Va
 	return value, nil
 }
 
-func (r *VaultSecretReconciler) generateValue(gen *vaultv1alpha1.VaultSecretGenerator) (v string, isBinary bool, e error) {
-	if gen.Name != generator_uuid && len(gen.Args) < 1 {
-		e = ErrInvalidGeneratorArgs
-		return
-	}
-
-	switch gen.Name { // TODO: use constants and use enum in
-	case "password":
-		n, digits, symbols := int(gen.Args[0]), 0, 0
-		if len(gen.Args) > 1 {
-			digits = int(gen.Args[1])
-		}
-		if len(gen.Args) > 2 {
-			symbols = int(gen.Args[2])
-		}
-		var err error
-		v, err = util.RandPassword(n, digits, symbols)
-		if err != nil {
-			e = fmt.Errorf("failed to generate password: %w", err)
-			return
-		}
-	case "string":
-		v = util.RandString(int(gen.Args[0]))
-	case "bytes":
-		v = b64.StdEncoding.EncodeToString(util.RandBytes(int(gen.Args[0])))
-		isBinary = true
-	case generator_uuid:
-		v = uuid.New().String()
-	case "rsa":
-		rsaBytes, err := util.GenerateRSA(gen.Args[0])
-		if err != nil {
-			e = fmt.Errorf("failed to generate rsa: %w", err)
-			return
-		}
-		v = b64.StdEncoding.EncodeToString(rsaBytes)
-		isBinary = true
-	case "ecdsa":
-		ecdsaBytes, err := util.GenerateECDSA(gen.Args[0])
-		if err != nil {
-			e = fmt.Errorf("failed to generate ecdsa: %w", err)
-			return
-		}
-		v = b64.StdEncoding.EncodeToString(ecdsaBytes)
-		isBinary = true
-	default:
-		e = ErrUnknownGenerator
-	}
-	return
-}
-
 func (r *VaultSecretReconciler) checkPermission(vaultSecret *vaultv1alpha1.VaultSecret, vaultPath string) error {
-	// TODO: we should implement CRDs to control permissions to vault secrets! SUPER IMPORTANT TO REMOVE THIS MADNESS!
-	segments := strings.Split(vaultPath, "/")
-	if len(segments) < 1 {
-		return ErrInvalidVaultPath
-	}
-
-	allowedEngines := strings.Split(os.Getenv("ALLOWED_ENGINES"), ",")
-
-	firstSegment := segments[0]
-	if firstSegment == "cert" {
-		return nil
-	}
-	if util.ContainsString(allowedEngines, firstSegment) {
-		// The Vault path should be scoped (e.g. <allowed-engine>/<namespace>/<key-name>) and thus consist
-		// of at least 3 parts.
-		if len(segments) < 3 {
-			return ErrInvalidVaultPath
-		}
-
-		// The scope-part should either match the namespace the VaultSecret is deployed to or
-		// some pre-defined shared identifiers.
-		secondSegment := segments[1]
-		sharedPaths := strings.Split(os.Getenv("SHARED_PATHS"), ",")
-		switch {
-		case secondSegment == vaultSecret.ObjectMeta.Namespace: // namespace of the VaultSecret itself
-			return nil
-		case util.ContainsString(sharedPaths, secondSegment):
-			return nil
-		}
-		r.Log.Error(ErrPermissionDenied, "second segment must be equal to VaultSecret namespace or in shared paths", "secondSegment", secondSegment, "namespace", vaultSecret.ObjectMeta.Namespace, "sharedPaths", os.Getenv("SHARED_PATHS"))
+	err := permission.Check(vaultSecret.ObjectMeta.Namespace, vaultPath)
+	if err == ErrPermissionDenied {
+		permissionDenials.WithLabelValues(vaultSecret.Namespace).Inc()
+		r.Log.Error(err, "second segment must be equal to VaultSecret namespace or in shared paths", "path", vaultPath, "namespace", vaultSecret.ObjectMeta.Namespace, "sharedPaths", os.Getenv("SHARED_PATHS"))
 	}
-	return ErrPermissionDenied
+	return err
 }
 
 func (r *VaultSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -498,3 +724,9 @@ func (r *VaultSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Named("vaultoperator").
 		Complete(r)
 }
+
+// isBinary returns whether the value of the location is base64 encoded binary data. Generators record this
+// in a hidden field of the entry, so locations of generated values do not need to be marked as binary.
+func isBinary(location *vaultv1alpha1.VaultSecretLocation, fields map[string]string) bool {
+	return location.IsBinary || fields[vault.GetIsBinaryKey(location.Field)] == "1"
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+const (
+	// importedByLabel is set on VaultSecrets created by a VaultSecretImport to its name.
+	importedByLabel = "vault.finleap.cloud/imported-by"
+	// importRetryInterval is the delay until failed imports are retried.
+	importRetryInterval = time.Minute
+)
+
+// VaultSecretImportReconciler imports existing secrets into vault and creates VaultSecrets adopting them
+type VaultSecretImportReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Vault    *vault.Client
+	Scheme   *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecretimports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecretimports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=vault.finleap.cloud,resources=vaultsecrets,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;patch
+func (r *VaultSecretImportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("vaultsecretimport", req.NamespacedName)
+
+	vaultSecretImport := &vaultv1alpha1.VaultSecretImport{}
+	if err := r.Get(ctx, req.NamespacedName, vaultSecretImport); err != nil {
+		return ctrl.Result{}, ignoreNotFound(err)
+	}
+	base := vaultSecretImport.DeepCopy()
+
+	selector, err := metav1.LabelSelectorAsSelector(&vaultSecretImport.Spec.Selector)
+	if err != nil {
+		// Nothing to retry until the selector is fixed
+		vaultSecretImport.SetReadyCondition(metav1.ConditionFalse, vaultv1alpha1.ReasonImportFailed, fmt.Sprintf("Selector is invalid: %v", err))
+		return ctrl.Result{}, r.patchStatus(ctx, base, vaultSecretImport)
+	}
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(req.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		// Secrets are only imported once, afterwards their VaultSecret is the source of truth
+		if state := vaultSecretImport.Status.GetSecret(secret.Name); state != nil && state.Phase != vaultv1alpha1.FailedPhase {
+			continue
+		}
+		state := r.importSecret(ctx, log, vaultSecretImport, secret)
+		vaultSecretImport.Status.SetSecret(state)
+	}
+
+	status := vaultSecretImport.Status
+	if status.Failed > 0 {
+		vaultSecretImport.SetReadyCondition(metav1.ConditionFalse, vaultv1alpha1.ReasonImportFailed, fmt.Sprintf("Import of %d secrets failed, it is retried", status.Failed))
+	} else {
+		vaultSecretImport.SetReadyCondition(metav1.ConditionTrue, vaultv1alpha1.ReasonImported, fmt.Sprintf("Imported %d secrets", status.Imported))
+	}
+	if err := r.patchStatus(ctx, base, vaultSecretImport); err != nil {
+		log.Error(err, "status update failed")
+		return ctrl.Result{}, err
+	}
+	if status.Failed > 0 {
+		return ctrl.Result{RequeueAfter: importRetryInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// importSecret writes the data of the secret to vault, marks the secret as adoptable and creates a
+// VaultSecret of the same name managing it. Secrets managed by others are skipped.
+func (r *VaultSecretImportReconciler) importSecret(ctx context.Context, log logr.Logger, vaultSecretImport *vaultv1alpha1.VaultSecretImport, secret *corev1.Secret) vaultv1alpha1.VaultSecretImportSecretStatus {
+	state := vaultv1alpha1.VaultSecretImportSecretStatus{Name: secret.Name, Phase: vaultv1alpha1.SkippedPhase}
+	if owner := metav1.GetControllerOf(secret); owner != nil {
+		state.Message = fmt.Sprintf("Secret is managed by %s %s", owner.Kind, owner.Name)
+		return state
+	}
+	if secret.Type == corev1.SecretTypeServiceAccountToken {
+		state.Message = "Service account tokens are not imported"
+		return state
+	}
+	if len(secret.Data) == 0 {
+		state.Message = "Secret has no data"
+		return state
+	}
+
+	vaultSecret := ImportedVaultSecret(secret, strings.Join([]string{vaultSecretImport.Spec.GetEngine(), secret.Namespace, secret.Name}, "/"), vaultv1alpha1.OwnerCreationPolicy)
+	vaultSecret.Labels = map[string]string{importedByLabel: vaultSecretImport.Name}
+	state.Path = vaultSecret.Spec.Data[0].Location.Path
+
+	existing := &vaultv1alpha1.VaultSecret{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(vaultSecret), existing); err == nil {
+		// A previous attempt may have failed after the VaultSecret was created
+		if existing.Labels[importedByLabel] != vaultSecretImport.Name {
+			state.Message = fmt.Sprintf("VaultSecret %s exists", existing.Name)
+			return state
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return importFailed(state, err)
+	}
+
+	if err := r.importData(ctx, secret, state.Path); err != nil {
+		log.Error(err, "import failed", "secret", secret.Name, "path", state.Path)
+		r.Recorder.Event(vaultSecretImport, corev1.EventTypeWarning, "Problem", fmt.Sprintf("Import of secret %s failed: %v", secret.Name, err))
+		return importFailed(state, err)
+	}
+	if err := r.Create(ctx, vaultSecret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return importFailed(state, err)
+	}
+
+	log.Info("imported secret", "secret", secret.Name, "path", state.Path)
+	r.Recorder.Event(vaultSecretImport, corev1.EventTypeNormal, "Imported", fmt.Sprintf("Imported secret %s to %s", secret.Name, state.Path))
+	state.Phase = vaultv1alpha1.ImportedPhase
+	state.Message = ""
+	return state
+}
+
+// importData writes the data of the secret to the vault path and annotates the secret, so the VaultSecret
+// adopts it. Fields which exist in vault with other values are not overwritten.
+func (r *VaultSecretImportReconciler) importData(ctx context.Context, secret *corev1.Secret, path string) error {
+	if err := permission.Check(secret.Namespace, path); err != nil {
+		if errors.Is(err, ErrPermissionDenied) {
+			permissionDenials.WithLabelValues(secret.Namespace).Inc()
+		}
+		return err
+	}
+	data := ImportedVaultData(secret)
+	fields, err := r.Vault.GetAll(ctx, path, 0)
+	if err != nil && err != vault.ErrNotFound {
+		return err
+	}
+	if err := ImportConflict(path, fields, data); err != nil {
+		return err
+	}
+	if err := r.Vault.CreateOrUpdate(ctx, path, data); err != nil {
+		return err
+	}
+
+	if _, ok := secret.Annotations[secretHashAnnotation]; ok {
+		return nil
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	metav1.SetMetaDataAnnotation(&secret.ObjectMeta, secretHashAnnotation, secretHash(secret))
+	return r.Patch(ctx, secret, patch)
+}
+
+func importFailed(state vaultv1alpha1.VaultSecretImportSecretStatus, err error) vaultv1alpha1.VaultSecretImportSecretStatus {
+	state.Phase = vaultv1alpha1.FailedPhase
+	state.Message = err.Error()
+	return state
+}
+
+// patchStatus writes the status of the VaultSecretImport if it differs from the status of base.
+func (r *VaultSecretImportReconciler) patchStatus(ctx context.Context, base, vaultSecretImport *vaultv1alpha1.VaultSecretImport) error {
+	if equality.Semantic.DeepEqual(base.Status, vaultSecretImport.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, vaultSecretImport, client.MergeFrom(base))
+}
+
+// importsForSecret returns requests for the VaultSecretImports selecting the secret.
+func (r *VaultSecretImportReconciler) importsForSecret(obj client.Object) []reconcile.Request {
+	imports := &vaultv1alpha1.VaultSecretImportList{}
+	if err := r.List(context.Background(), imports, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "listing VaultSecretImports failed")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range imports.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&imports.Items[i].Spec.Selector)
+		if err == nil && selector.Matches(labels.Set(obj.GetLabels())) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&imports.Items[i])})
+		}
+	}
+	return requests
+}
+
+func (r *VaultSecretImportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor("vaultsecretimport-controller")
+	r.Scheme = mgr.GetScheme()
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&vaultv1alpha1.VaultSecretImport{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.importsForSecret)).
+		Complete(r)
+}
+
+// ImportedVaultSecret returns a VaultSecret of the same name as the secret, reading all of its keys from
+// the fields of the same name of the vault path.
+func ImportedVaultSecret(secret *corev1.Secret, path string, policy vaultv1alpha1.SecretCreationPolicy) *vaultv1alpha1.VaultSecret {
+	vaultSecret := &vaultv1alpha1.VaultSecret{
+		TypeMeta: metav1.TypeMeta{APIVersion: vaultv1alpha1.GroupVersion.String(), Kind: "VaultSecret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		},
+		Spec: vaultv1alpha1.VaultSecretSpec{
+			SecretType:     secret.Type,
+			SecretLabels:   secret.Labels,
+			CreationPolicy: policy,
+		},
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		vaultSecret.Spec.Data = append(vaultSecret.Spec.Data, vaultv1alpha1.VaultSecretData{
+			Name:     key,
+			Location: &vaultv1alpha1.VaultSecretLocation{Path: path, Field: key},
+		})
+	}
+	return vaultSecret
+}
+
+// ImportConflict returns an ErrImportConflict naming the first field of data which exists in fields with another
+// value, nil if data can be written without overwriting a value.
+func ImportConflict(path string, fields map[string]string, data map[string]interface{}) error {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if existing, ok := fields[k]; ok && existing != data[k] {
+			return fmt.Errorf("%w: %s#%s", ErrImportConflict, path, k)
+		}
+	}
+	return nil
+}
+
+// ImportedVaultData returns the fields of the secret to store in vault. Values which are not valid UTF-8
+// are stored base64 encoded and marked as binary.
+func ImportedVaultData(secret *corev1.Secret) map[string]interface{} {
+	data := make(map[string]interface{}, len(secret.Data))
+	for key, value := range secret.Data {
+		if utf8.Valid(value) {
+			data[key] = string(value)
+			continue
+		}
+		data[key] = b64.StdEncoding.EncodeToString(value)
+		data[vault.GetIsBinaryKey(key)] = "1"
+	}
+	return data
+}
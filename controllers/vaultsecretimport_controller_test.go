@@ -0,0 +1,79 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+var _ = Describe("VaultSecretImportReconciler", func() {
+	It("can import secrets", func() {
+		label := map[string]string{"import": newTestName()}
+		selected := newSecret(newTestName())
+		selected.Labels = label
+		Expect(k8sClient.Create(ctx, selected)).To(Succeed())
+		controller := true
+		owned := newSecret(newTestName())
+		owned.Labels = label
+		owned.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "other", UID: "uid", Controller: &controller,
+		}}
+		Expect(k8sClient.Create(ctx, owned)).To(Succeed())
+		Expect(k8sClient.Create(ctx, newSecret(newTestName()))).To(Succeed())
+
+		vsi := &vaultv1alpha1.VaultSecretImport{
+			ObjectMeta: metav1.ObjectMeta{Name: newTestName(), Namespace: testNamespace},
+			Spec:       vaultv1alpha1.VaultSecretImportSpec{Selector: metav1.LabelSelector{MatchLabels: label}},
+		}
+		Expect(k8sClient.Create(ctx, vsi)).To(Succeed())
+		key := types.NamespacedName{Name: vsi.Name, Namespace: testNamespace}
+		result, err := testVSIR.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+
+		Expect(k8sClient.Get(ctx, key, vsi)).To(Succeed())
+		Expect(vsi.Status.Imported).To(Equal(int32(1)))
+		Expect(vsi.Status.Secrets).To(HaveLen(2))
+		Expect(vsi.Status.GetSecret(owned.Name).Phase).To(Equal(vaultv1alpha1.SkippedPhase))
+		state := vsi.Status.GetSecret(selected.Name)
+		Expect(state.Phase).To(Equal(vaultv1alpha1.ImportedPhase))
+		Expect(state.Path).To(Equal("app/test/" + selected.Name))
+		condition := meta.FindStatusCondition(vsi.Status.Conditions, vaultv1alpha1.ReadyCondition)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+
+		fields, err := testVaultClient.GetAll(ctx, state.Path, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fields).To(Equal(map[string]string{"foo": "nothing", "bar": "nothingelse"}))
+
+		// The created VaultSecret adopts the existing secret
+		vs := &vaultv1alpha1.VaultSecret{}
+		Expect(k8sClient.Get(ctx, namespacedName(selected), vs)).To(Succeed())
+		Expect(vs.Labels[importedByLabel]).To(Equal(vsi.Name))
+		mustReconcile(vs)
+		s := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, namespacedName(selected), s)).To(Succeed())
+		Expect(metav1.GetControllerOf(s)).ToNot(BeNil())
+		Expect(s.Data["foo"]).To(Equal([]byte("nothing")))
+	})
+})
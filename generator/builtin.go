@@ -0,0 +1,246 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"context"
+	"crypto"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/util"
+)
+
+// builtins are the generators every registry starts with.
+var builtins = map[vaultv1alpha1.VaultSecretGeneratorName]Generator{
+	vaultv1alpha1.PasswordGenerator:    GeneratorFunc(generatePasswordValue),
+	vaultv1alpha1.StringGenerator:      GeneratorFunc(generateString),
+	vaultv1alpha1.BytesGenerator:       GeneratorFunc(generateBytes),
+	vaultv1alpha1.UUIDGenerator:        GeneratorFunc(generateUUID),
+	vaultv1alpha1.RSAGenerator:         GeneratorFunc(generateRSA),
+	vaultv1alpha1.ECDSAGenerator:       GeneratorFunc(generateECDSA),
+	vaultv1alpha1.Ed25519Generator:     GeneratorFunc(generateEd25519),
+	vaultv1alpha1.SSHGenerator:         GeneratorFunc(generateSSHValue),
+	vaultv1alpha1.CertificateGenerator: GeneratorFunc(generateCertificateValue),
+	vaultv1alpha1.HtpasswdGenerator:    GeneratorFunc(generateHtpasswdValue),
+}
+
+func generatePasswordValue(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	v, err := generatePassword(gen)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{Value: v}, nil
+}
+
+func generateString(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	str := gen.GetString()
+	if str.Length < 1 {
+		return nil, ErrInvalidArgs
+	}
+	alphabet := str.Alphabet
+	if alphabet == "" {
+		alphabet = util.DefaultAlphabet
+	}
+	v, err := util.RandStringFrom(str.Length, alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate string: %w", err)
+	}
+	logf.FromContext(ctx).V(1).Info("generated string", "length", str.Length, "entropyBits", util.Entropy(str.Length, alphabet))
+	return &Value{Value: v}, nil
+}
+
+func generateBytes(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	b := gen.GetBytes()
+	if b.Length < 1 {
+		return nil, ErrInvalidArgs
+	}
+	raw, err := util.RandBytes(b.Length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate bytes: %w", err)
+	}
+	logf.FromContext(ctx).V(1).Info("generated bytes", "length", b.Length, "entropyBits", 8*b.Length)
+	switch b.Encoding {
+	case vaultv1alpha1.Base64Encoding:
+		return &Value{Value: b64.StdEncoding.EncodeToString(raw)}, nil
+	case vaultv1alpha1.HexEncoding:
+		return &Value{Value: hex.EncodeToString(raw)}, nil
+	default:
+		return &Value{Value: b64.StdEncoding.EncodeToString(raw), IsBinary: true}, nil
+	}
+}
+
+func generateUUID(_ context.Context, _ *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	return &Value{Value: uuid.New().String()}, nil
+}
+
+func generateRSA(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	rsa := gen.GetRSA()
+	format := util.FormatPKCS1
+	if rsa.Format != "" {
+		format = string(rsa.Format)
+	}
+	return generateKey("rsa", string(vaultv1alpha1.RSAKey), rsa.Bits, "", format, true)
+}
+
+func generateECDSA(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	ecdsa := gen.GetECDSA()
+	format := util.FormatSEC1
+	if ecdsa.Format != "" {
+		format = string(ecdsa.Format)
+	}
+	return generateKey("ecdsa", string(vaultv1alpha1.ECDSAKey), 0, string(ecdsa.Curve), format, true)
+}
+
+func generateEd25519(_ context.Context, _ *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	// Unlike the rsa and ecdsa generators the key is stored as plain PEM
+	return generateKey("ed25519", string(vaultv1alpha1.Ed25519Key), 0, "", util.FormatPKCS8, false)
+}
+
+func generateSSHValue(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	return generateSSH(gen.GetSSH())
+}
+
+func generateCertificateValue(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	return generateCertificate(gen.GetCertificate())
+}
+
+func generateHtpasswdValue(_ context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	return generateHtpasswd(gen)
+}
+
+func generatePassword(gen *vaultv1alpha1.VaultSecretDataGenerator) (string, error) {
+	p := gen.GetPassword()
+	if p.Length < 1 {
+		return "", ErrInvalidArgs
+	}
+	v, err := util.GeneratePassword(p.Length, p.Digits, p.Symbols, p.NoUpper, p.RepeatAllowed(), p.Charset, p.ExcludeCharacters)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return v, nil
+}
+
+// generateKey generates a private key and its public key. If binary is set the private key is stored base64 encoded
+// as binary value, otherwise as plain PEM.
+func generateKey(name, keyType string, bits int, curve, format string, binary bool) (*Value, error) {
+	key, err := util.GenerateKey(keyType, bits, curve)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %w", name, err)
+	}
+	private, err := util.MarshalPrivateKey(key, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	public, err := util.MarshalPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s public key: %w", name, err)
+	}
+	value := &Value{
+		Value: string(private),
+		Parts: map[string]string{
+			vaultv1alpha1.PublicKeyPart: string(public),
+		},
+	}
+	if binary {
+		value.Value, value.IsBinary = b64.StdEncoding.EncodeToString(private), true
+	}
+	return value, nil
+}
+
+func generateSSH(ssh *vaultv1alpha1.VaultSecretSSHGenerator) (*Value, error) {
+	key, err := util.GenerateKey(string(ssh.KeyType), ssh.Bits, string(ssh.Curve))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ssh key: %w", err)
+	}
+	private, authorized, err := util.MarshalSSHKeyPair(key, ssh.Comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ssh key: %w", err)
+	}
+	return &Value{
+		Value: string(private),
+		Parts: map[string]string{
+			vaultv1alpha1.AuthorizedKeyPart: string(authorized),
+		},
+	}, nil
+}
+
+func generateCertificate(cert *vaultv1alpha1.VaultSecretCertificateGenerator) (*Value, error) {
+	key, err := util.GenerateKey(string(cert.KeyType), cert.Bits, string(cert.Curve))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+	ips := make([]net.IP, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, ErrInvalidArgs
+		}
+		ips = append(ips, parsed)
+	}
+	certificate, err := util.GenerateCertificate(key, cert.CommonName, cert.DNSNames, ips, cert.Validity.Duration, cert.IsCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate: %w", err)
+	}
+	return keyWithParts(key, map[string]string{
+		vaultv1alpha1.CertificatePart: string(certificate),
+	})
+}
+
+func generateHtpasswd(gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	if gen.Htpasswd == nil || gen.Htpasswd.Username == "" {
+		return nil, ErrInvalidArgs
+	}
+	password, err := generatePassword(gen)
+	if err != nil {
+		return nil, err
+	}
+	cost := gen.Htpasswd.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := util.HashBcrypt(password, cost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	return &Value{
+		Value: password,
+		Parts: map[string]string{
+			vaultv1alpha1.HashPart:     hash,
+			vaultv1alpha1.HtpasswdPart: gen.Htpasswd.Username + ":" + hash,
+		},
+	}, nil
+}
+
+// keyWithParts returns the private key as plain PKCS #8 PEM together with its public key and the given parts.
+func keyWithParts(key crypto.Signer, parts map[string]string) (*Value, error) {
+	private, err := util.MarshalPrivateKey(key, util.FormatPKCS8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	public, err := util.MarshalPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	parts[vaultv1alpha1.PublicKeyPart] = string(public)
+	return &Value{Value: string(private), Parts: parts}, nil
+}
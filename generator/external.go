@@ -0,0 +1,211 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	// maxResponseSize limits the output read from external generators.
+	maxResponseSize = 1 << 20
+)
+
+// ExternalRequest is sent to generators defined by a VaultSecretGenerator resource, as JSON body of the
+// POST request for webhooks and on stdin for executables.
+type ExternalRequest struct {
+	// Name of the VaultSecretGenerator resource.
+	Generator string `json:"generator"`
+	// Parameters given in the generator configuration of the VaultSecret.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ExternalResponse is expected from generators defined by a VaultSecretGenerator resource, as JSON body of
+// the response for webhooks and on stdout for executables.
+type ExternalResponse struct {
+	// Generated value, base64 encoded if isBinary is set.
+	Value string `json:"value"`
+	// Whether the value is base64 encoded binary data.
+	IsBinary bool `json:"isBinary,omitempty"`
+	// Additional outputs written to the vault fields `<field>_<part>`.
+	Parts map[string]string `json:"parts,omitempty"`
+}
+
+// NewExternal returns the generator defined by the given resource. Executables are only looked up in
+// the plugin directory; exec generators are disabled if it is empty.
+func NewExternal(spec *vaultv1alpha1.VaultSecretGenerator, pluginDir string) (Generator, error) {
+	timeout := defaultTimeout
+	if spec.Spec.Timeout != nil {
+		timeout = spec.Spec.Timeout.Duration
+	}
+	switch {
+	case spec.Spec.Webhook != nil && spec.Spec.Exec == nil:
+		return newWebhookGenerator(spec.Name, spec.Spec.Webhook, timeout)
+	case spec.Spec.Exec != nil && spec.Spec.Webhook == nil:
+		return newExecGenerator(spec.Name, spec.Spec.Exec, pluginDir, timeout)
+	default:
+		return nil, ErrInvalidGeneratorCR
+	}
+}
+
+type webhookGenerator struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func newWebhookGenerator(name string, webhook *vaultv1alpha1.VaultSecretGeneratorWebhook, timeout time.Duration) (*webhookGenerator, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(webhook.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(webhook.CABundle) {
+			return nil, fmt.Errorf("ca bundle of generator %s does not contain any certificate", name)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &webhookGenerator{
+		name:   name,
+		url:    webhook.URL,
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+func (w *webhookGenerator) Generate(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	body, err := json.Marshal(&ExternalRequest{Generator: w.name, Params: gen.Params})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("generator %s failed with: %w", w.name, err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("generator %s failed with: %w", w.name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("generator %s responded with status %d", w.name, resp.StatusCode)
+	}
+	return parseResponse(out)
+}
+
+type execGenerator struct {
+	name    string
+	path    string
+	args    []string
+	timeout time.Duration
+}
+
+func newExecGenerator(name string, e *vaultv1alpha1.VaultSecretGeneratorExec, pluginDir string, timeout time.Duration) (*execGenerator, error) {
+	if pluginDir == "" {
+		return nil, ErrPluginsDisabled
+	}
+	// Only plain file names are accepted, so the executable can not escape the plugin directory
+	if e.Command == "" || e.Command == "." || e.Command == ".." || filepath.Base(e.Command) != e.Command {
+		return nil, ErrInvalidPlugin
+	}
+	return &execGenerator{
+		name:    name,
+		path:    filepath.Join(pluginDir, e.Command),
+		args:    e.Args,
+		timeout: timeout,
+	}, nil
+}
+
+func (e *execGenerator) Generate(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	body, err := json.Marshal(&ExternalRequest{Generator: e.name, Params: gen.Params})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.path, e.args...)
+	// The environment of the operator contains vault credentials and must not be passed on
+	cmd.Env = []string{}
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &limitedWriter{w: &stdout, n: maxResponseSize}
+	cmd.Stderr = &limitedWriter{w: &stderr, n: 1024}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("generator %s failed with: %w: %s", e.name, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseResponse(stdout.Bytes())
+}
+
+func parseResponse(out []byte) (*Value, error) {
+	resp := &ExternalResponse{}
+	if err := json.Unmarshal(out, resp); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidResponse, err)
+	}
+	if resp.Value == "" {
+		return nil, fmt.Errorf("%w: value is empty", ErrInvalidResponse)
+	}
+	if resp.IsBinary {
+		if _, err := b64.StdEncoding.DecodeString(resp.Value); err != nil {
+			return nil, fmt.Errorf("%w: binary value is not base64 encoded", ErrInvalidResponse)
+		}
+	}
+	for part := range resp.Parts {
+		// Parts must not clash with the hidden fields the operator keeps next to a value
+		if part == "" || strings.HasPrefix(part, ".") || strings.Contains(part, "/") {
+			return nil, fmt.Errorf("%w: part %q is not allowed", ErrInvalidResponse, part)
+		}
+	}
+	return &Value{Value: resp.Value, IsBinary: resp.IsBinary, Parts: resp.Parts}, nil
+}
+
+// limitedWriter discards everything written after n bytes.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	size := len(p)
+	if l.n <= 0 {
+		return size, nil
+	}
+	if len(p) > l.n {
+		p = p[:l.n]
+	}
+	written, err := l.w.Write(p)
+	l.n -= written
+	if err != nil {
+		return written, err
+	}
+	return size, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+)
+
+func newGeneratorResource(name string, spec vaultv1alpha1.VaultSecretGeneratorSpec) *vaultv1alpha1.VaultSecretGenerator {
+	return &vaultv1alpha1.VaultSecretGenerator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+}
+
+func TestWebhookGenerator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &ExternalRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&ExternalResponse{
+			Value: req.Generator + ":" + req.Params["prefix"],
+			Parts: map[string]string{"salt": "pepper"},
+		})
+	}))
+	defer server.Close()
+
+	gen, err := NewExternal(newGeneratorResource("token", vaultv1alpha1.VaultSecretGeneratorSpec{
+		Webhook: &vaultv1alpha1.VaultSecretGeneratorWebhook{URL: server.URL},
+	}), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	value, err := gen.Generate(context.Background(), &vaultv1alpha1.VaultSecretDataGenerator{
+		Name:   "token",
+		Params: map[string]string{"prefix": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value.Value != "token:abc" || value.Parts["salt"] != "pepper" {
+		t.Errorf("unexpected value %+v", value)
+	}
+	if fields := value.Fields("foo"); fields["foo_salt"] != "pepper" {
+		t.Errorf("expected part to be written to foo_salt, got %v", fields)
+	}
+}
+
+func TestWebhookGeneratorRejectsInvalidResponses(t *testing.T) {
+	for _, body := range []string{
+		`not json`,
+		`{"value": ""}`,
+		`{"value": "%%%", "isBinary": true}`,
+		`{"value": "foo", "parts": {".foo_isBinary": "1"}}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		gen, err := NewExternal(newGeneratorResource("invalid", vaultv1alpha1.VaultSecretGeneratorSpec{
+			Webhook: &vaultv1alpha1.VaultSecretGeneratorWebhook{URL: server.URL},
+		}), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := gen.Generate(context.Background(), &vaultv1alpha1.VaultSecretDataGenerator{Name: "invalid"}); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("expected %v for %s, got %v", ErrInvalidResponse, body, err)
+		}
+		server.Close()
+	}
+}
+
+func TestExecGenerator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec generators are tested with a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ -n \"$VAULT_TOKEN\" ]; then exit 1; fi\ncat > /dev/null\necho '{\"value\": \"'$1'\"}'\n"
+	if err := os.WriteFile(filepath.Join(dir, "echo-arg"), []byte(script), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("VAULT_TOKEN", "secret")
+
+	spec := vaultv1alpha1.VaultSecretGeneratorSpec{
+		Exec: &vaultv1alpha1.VaultSecretGeneratorExec{Command: "echo-arg", Args: []string{"generated"}},
+	}
+	if _, err := NewExternal(newGeneratorResource("exec", spec), ""); !errors.Is(err, ErrPluginsDisabled) {
+		t.Errorf("expected %v, got %v", ErrPluginsDisabled, err)
+	}
+	gen, err := NewExternal(newGeneratorResource("exec", spec), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	value, err := gen.Generate(context.Background(), &vaultv1alpha1.VaultSecretDataGenerator{Name: "exec"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value.Value != "generated" {
+		t.Errorf("expected generated, got %s", value.Value)
+	}
+
+	for _, command := range []string{"../sh", "/bin/sh", ".."} {
+		spec.Exec.Command = command
+		if _, err := NewExternal(newGeneratorResource("exec", spec), dir); !errors.Is(err, ErrInvalidPlugin) {
+			t.Errorf("expected %v for %s, got %v", ErrInvalidPlugin, command, err)
+		}
+	}
+}
+
+func TestExecGeneratorTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec generators are tested with a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sleep"), []byte("#!/bin/sh\nexec /bin/sleep 10\n"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gen, err := NewExternal(newGeneratorResource("sleep", vaultv1alpha1.VaultSecretGeneratorSpec{
+		Exec:    &vaultv1alpha1.VaultSecretGeneratorExec{Command: "sleep"},
+		Timeout: &metav1.Duration{Duration: 100 * time.Millisecond},
+	}), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now()
+	if _, err := gen.Generate(context.Background(), &vaultv1alpha1.VaultSecretDataGenerator{Name: "sleep"}); err == nil {
+		t.Error("expected timeout error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("generator was not stopped after its timeout")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	for _, name := range vaultv1alpha1.BuiltinGenerators {
+		if _, ok := registry.Get(name); !ok {
+			t.Errorf("built-in generator %s is not registered", name)
+		}
+	}
+	custom := GeneratorFunc(func(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+		return &Value{Value: "custom"}, nil
+	})
+	if err := registry.Register("custom", custom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register(vaultv1alpha1.UUIDGenerator, custom); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("expected %v, got %v", ErrAlreadyRegistered, err)
+	}
+	gen, ok := registry.Get("custom")
+	if !ok {
+		t.Fatal("custom generator is not registered")
+	}
+	if value, err := gen.Generate(context.Background(), &vaultv1alpha1.VaultSecretDataGenerator{Name: "custom"}); err != nil || value.Value != "custom" {
+		t.Errorf("unexpected result %v, %v", value, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generator implements the generation of values which do not exist in vault yet.
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+var (
+	ErrUnknownGenerator   = errors.New("no generator specified")
+	ErrInvalidArgs        = errors.New("invalid arguments for specified generator")
+	ErrAlreadyRegistered  = errors.New("generator is already registered")
+	ErrPluginsDisabled    = errors.New("exec generators are disabled as no plugin directory is configured")
+	ErrInvalidPlugin      = errors.New("exec generator must refer to an executable within the plugin directory")
+	ErrInvalidResponse    = errors.New("invalid response of external generator")
+	ErrInvalidGeneratorCR = errors.New("exactly one of webhook or exec is required")
+)
+
+// Generator generates a value for the given configuration.
+type Generator interface {
+	Generate(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error)
+}
+
+// GeneratorFunc adapts a function to the Generator interface.
+type GeneratorFunc func(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error)
+
+// Generate calls f(ctx, gen).
+func (f GeneratorFunc) Generate(ctx context.Context, gen *vaultv1alpha1.VaultSecretDataGenerator) (*Value, error) {
+	return f(ctx, gen)
+}
+
+// Value is the result of a generator. Additional outputs like public keys are written to the
+// vault fields `<field>_<part>` next to the generated value.
+type Value struct {
+	Value    string
+	IsBinary bool
+	Parts    map[string]string
+}
+
+// Fields returns the vault fields to write if the value was generated for the given field.
+func (v *Value) Fields(field string) map[string]interface{} {
+	fields := map[string]interface{}{
+		field: v.Value,
+	}
+	if v.IsBinary {
+		fields[vault.GetIsBinaryKey(field)] = "1"
+	}
+	for part, value := range v.Parts {
+		fields[vaultv1alpha1.GeneratedPartField(field, part)] = value
+	}
+	return fields
+}
+
+// Registry holds the generators known to the operator by name.
+type Registry struct {
+	mu         sync.RWMutex
+	generators map[vaultv1alpha1.VaultSecretGeneratorName]Generator
+}
+
+// NewRegistry returns a registry containing the built-in generators.
+func NewRegistry() *Registry {
+	r := &Registry{generators: map[vaultv1alpha1.VaultSecretGeneratorName]Generator{}}
+	for name, gen := range builtins {
+		r.generators[name] = gen
+	}
+	return r
+}
+
+// Register adds a generator with the given name. Names can only be registered once.
+func (r *Registry) Register(name vaultv1alpha1.VaultSecretGeneratorName, gen Generator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.generators[name]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyRegistered, name)
+	}
+	r.generators[name] = gen
+	return nil
+}
+
+// Get returns the generator registered with the given name.
+func (r *Registry) Get(name vaultv1alpha1.VaultSecretGeneratorName) (Generator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	gen, ok := r.generators[name]
+	return gen, ok
+}
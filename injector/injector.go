@@ -0,0 +1,199 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package injector implements a mutating webhook which injects values from vault into pods at admission
+// time, without a secret object holding them.
+package injector
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+const (
+	// InjectLabel has to be set to "enabled" on pods for the webhook to be called.
+	InjectLabel = "vault.finleap.cloud/inject"
+	// EnvAnnotationPrefix is followed by the name of the variable, the value refers to the vault field as
+	// <path>#<field>, e.g. vault.finleap.cloud/inject-env-DB_PASS: app/mynamespace/db#password.
+	EnvAnnotationPrefix = "vault.finleap.cloud/inject-env-"
+)
+
+var (
+	ErrInvalidAnnotation = errors.New("invalid injection annotation")
+)
+
+// Reader reads entries from vault, it is implemented by vault.Client.
+type Reader interface {
+	GetAll(ctx context.Context, path string, version int) (map[string]string, error)
+}
+
+// The webhook fails closed, so pods are not started without their values. It is only called for pods with InjectLabel,
+// the object and namespace selectors are added by config/webhook/pod_injector_patch.yaml since the marker can't
+// express them, so an outage of the operator or vault only blocks pods which opted in.
+// +kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,failurePolicy=fail,groups="",resources=pods,verbs=create,versions=v1,name=mpod.vault.finleap.cloud,sideEffects=None,admissionReviewVersions=v1
+
+// PodInjector resolves the injection annotations of pods and adds the values from vault to their containers.
+type PodInjector struct {
+	Vault Reader
+	Log   logr.Logger
+
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &PodInjector{}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (i *PodInjector) InjectDecoder(d *admission.Decoder) error {
+	i.decoder = d
+	return nil
+}
+
+// reference is a vault field injected under a name.
+type reference struct {
+	name  string
+	path  string
+	field string
+}
+
+// Handle implements admission.Handler.
+func (i *PodInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pod := &corev1.Pod{}
+	if err := i.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// Pods which did not opt in are never mutated, even if the selector of the webhook is missing
+	if pod.Labels[InjectLabel] != "enabled" {
+		return admission.Allowed("injection not enabled")
+	}
+
+	refs, err := parseAnnotations(pod.Annotations)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if len(refs) == 0 {
+		return admission.Allowed("nothing to inject")
+	}
+
+	values, err := i.resolve(ctx, req.Namespace, refs)
+	if err != nil {
+		i.Log.Info("injection denied", "namespace", req.Namespace, "pod", pod.GenerateName+pod.Name, "error", err.Error())
+		return admission.Denied(err.Error())
+	}
+
+	injectEnv(pod, refs, values)
+
+	marshaled, err := json.Marshal(pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// parseAnnotations returns the references of the injection annotations sorted by name.
+func parseAnnotations(annotations map[string]string) ([]reference, error) {
+	var refs []reference
+	for key, value := range annotations {
+		if !strings.HasPrefix(key, EnvAnnotationPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, EnvAnnotationPrefix)
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("%w: %s is not a valid name: %s", ErrInvalidAnnotation, name, strings.Join(errs, ", "))
+		}
+		vaultPath, field, ok := strings.Cut(value, "#")
+		vaultPath = strings.Trim(vaultPath, "/")
+		if !ok || vaultPath == "" || field == "" {
+			return nil, fmt.Errorf("%w: %s has to refer to <path>#<field>", ErrInvalidAnnotation, key)
+		}
+		refs = append(refs, reference{name: name, path: vaultPath, field: field})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+	return refs, nil
+}
+
+// resolve reads the values of the references from vault, each path is only read once.
+func (i *PodInjector) resolve(ctx context.Context, namespace string, refs []reference) (map[string]string, error) {
+	entries := map[string]map[string]string{}
+	values := map[string]string{}
+	for _, ref := range refs {
+		if err := permission.Check(namespace, ref.path); err != nil {
+			return nil, fmt.Errorf("%s: %w", ref.path, err)
+		}
+		fields, ok := entries[ref.path]
+		if !ok {
+			var err error
+			if fields, err = i.Vault.GetAll(ctx, ref.path, 0); err != nil {
+				return nil, fmt.Errorf("reading %s failed with: %w", ref.path, err)
+			}
+			entries[ref.path] = fields
+		}
+		value, ok := fields[ref.field]
+		if !ok {
+			return nil, fmt.Errorf("%s#%s: %w", ref.path, ref.field, vault.ErrNotFound)
+		}
+		if fields[vault.GetIsBinaryKey(ref.field)] == "1" {
+			decoded, err := b64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+		values[ref.name] = value
+	}
+	return values, nil
+}
+
+// injectEnv sets the values as environment variables of all containers, replacing variables of the same name.
+func injectEnv(pod *corev1.Pod, refs []reference, values map[string]string) {
+	var env []corev1.EnvVar
+	for _, ref := range refs {
+		env = append(env, corev1.EnvVar{Name: ref.name, Value: values[ref.name]})
+	}
+	for i := range pod.Spec.InitContainers {
+		pod.Spec.InitContainers[i].Env = mergeEnv(pod.Spec.InitContainers[i].Env, env)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].Env = mergeEnv(pod.Spec.Containers[i].Env, env)
+	}
+}
+
+func mergeEnv(env []corev1.EnvVar, injected []corev1.EnvVar) []corev1.EnvVar {
+	for _, e := range injected {
+		replaced := false
+		for i := range env {
+			if env[i].Name == e.Name {
+				env[i] = e
+				replaced = true
+			}
+		}
+		if !replaced {
+			env = append(env, e)
+		}
+	}
+	return env
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package injector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/finleap-connect/vaultoperator/permission"
+	"github.com/finleap-connect/vaultoperator/vault"
+)
+
+type fakeReader map[string]map[string]string
+
+func (f fakeReader) GetAll(ctx context.Context, path string, version int) (map[string]string, error) {
+	fields, ok := f[path]
+	if !ok {
+		return nil, vault.ErrNotFound
+	}
+	return fields, nil
+}
+
+func newInjector(t *testing.T) *PodInjector {
+	t.Setenv("ALLOWED_ENGINES", "app")
+	t.Setenv("SHARED_PATHS", "shared")
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &PodInjector{
+		Vault: fakeReader{
+			"app/test/db":     {"password": "secret", "user": "admin"},
+			"app/shared/bin":  {"key": "AAEC", ".key_isBinary": "1"},
+			"app/other/db":    {"password": "other"},
+			"app/test/broken": {},
+		},
+		Log: logf.Log,
+	}
+	if err := i.InjectDecoder(decoder); err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+func newPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod",
+			Namespace:   "test",
+			Labels:      map[string]string{InjectLabel: "enabled"},
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app",
+				Env:   []corev1.EnvVar{{Name: "DB_USER", Value: "default"}, {Name: "OTHER", Value: "kept"}},
+			}},
+		},
+	}
+}
+
+func handle(t *testing.T, i *PodInjector, pod *corev1.Pod) admission.Response {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Namespace: "test",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+}
+
+func TestParseAnnotations(t *testing.T) {
+	refs, err := parseAnnotations(map[string]string{
+		EnvAnnotationPrefix + "DB_USER": "app/test/db#user",
+		EnvAnnotationPrefix + "DB_PASS": "/app/test/db/#password",
+		"other":                         "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []reference{
+		{name: "DB_PASS", path: "app/test/db", field: "password"},
+		{name: "DB_USER", path: "app/test/db", field: "user"},
+	}
+	if len(refs) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, refs)
+	}
+	for i := range expected {
+		if refs[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], refs[i])
+		}
+	}
+
+	for _, annotations := range []map[string]string{
+		{EnvAnnotationPrefix + "1INVALID": "app/test/db#user"},
+		{EnvAnnotationPrefix + "NO_FIELD": "app/test/db"},
+		{EnvAnnotationPrefix + "EMPTY_FIELD": "app/test/db#"},
+		{EnvAnnotationPrefix + "EMPTY_PATH": "#user"},
+	} {
+		if _, err := parseAnnotations(annotations); !errors.Is(err, ErrInvalidAnnotation) {
+			t.Errorf("expected invalid annotation for %v, got %v", annotations, err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	i := newInjector(t)
+	values, err := i.resolve(context.Background(), "test", []reference{
+		{name: "DB_PASS", path: "app/test/db", field: "password"},
+		{name: "KEY", path: "app/shared/bin", field: "key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["DB_PASS"] != "secret" {
+		t.Errorf("expected secret, got %q", values["DB_PASS"])
+	}
+	if values["KEY"] != "\x00\x01\x02" {
+		t.Errorf("expected decoded binary value, got %q", values["KEY"])
+	}
+
+	if _, err := i.resolve(context.Background(), "test", []reference{{name: "A", path: "app/other/db", field: "password"}}); !errors.Is(err, permission.ErrPermissionDenied) {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+	if _, err := i.resolve(context.Background(), "test", []reference{{name: "A", path: "app/test/broken", field: "password"}}); !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestInjectEnv(t *testing.T) {
+	pod := newPod(nil)
+	pod.Spec.InitContainers = []corev1.Container{{Name: "init"}}
+	injectEnv(pod, []reference{{name: "DB_PASS"}, {name: "DB_USER"}}, map[string]string{"DB_PASS": "secret", "DB_USER": "admin"})
+
+	expected := []corev1.EnvVar{{Name: "DB_USER", Value: "admin"}, {Name: "OTHER", Value: "kept"}, {Name: "DB_PASS", Value: "secret"}}
+	env := pod.Spec.Containers[0].Env
+	if len(env) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, env)
+	}
+	for i := range expected {
+		if env[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], env[i])
+		}
+	}
+	if len(pod.Spec.InitContainers[0].Env) != 2 {
+		t.Errorf("expected variables in init containers, got %v", pod.Spec.InitContainers[0].Env)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	i := newInjector(t)
+
+	if resp := handle(t, i, newPod(nil)); !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("expected pod without annotations to be allowed unchanged, got %v", resp)
+	}
+	resp := handle(t, i, newPod(map[string]string{EnvAnnotationPrefix + "DB_PASS": "app/test/db#password"}))
+	if !resp.Allowed || len(resp.Patches) == 0 {
+		t.Errorf("expected pod to be patched, got %v", resp)
+	}
+	// Pods without the label are neither patched nor denied
+	for _, annotations := range []map[string]string{
+		{EnvAnnotationPrefix + "DB_PASS": "app/test/db#password"},
+		{EnvAnnotationPrefix + "DB_PASS": "app/other/db#password"},
+	} {
+		pod := newPod(annotations)
+		pod.Labels = nil
+		if resp := handle(t, i, pod); !resp.Allowed || len(resp.Patches) != 0 {
+			t.Errorf("expected pod without label to be allowed unchanged, got %v", resp)
+		}
+	}
+	for _, annotations := range []map[string]string{
+		{EnvAnnotationPrefix + "DB_PASS": "app/other/db#password"},
+		{EnvAnnotationPrefix + "DB_PASS": "app/test/db"},
+	} {
+		if resp := handle(t, i, newPod(annotations)); resp.Allowed {
+			t.Errorf("expected pod with %v to be denied", annotations)
+		}
+	}
+}
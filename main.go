@@ -15,9 +15,11 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -29,9 +31,13 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	vaultv1alpha1 "github.com/finleap-connect/vaultoperator/api/v1alpha1"
 	"github.com/finleap-connect/vaultoperator/controllers"
+	"github.com/finleap-connect/vaultoperator/generator"
+	"github.com/finleap-connect/vaultoperator/injector"
+	"github.com/finleap-connect/vaultoperator/tracing"
 	"github.com/finleap-connect/vaultoperator/vault"
 	//+kubebuilder:scaffold:imports
 )
@@ -58,6 +64,16 @@ func main() {
 		enableLeaderElection bool
 		vaultNamespace       string
 		probeAddr            string
+		generatorPluginDir   string
+		vaultCacheTTL        time.Duration
+		healthCacheTTL       time.Duration
+		unavailableThreshold time.Duration
+		vaultQPS             float64
+		vaultBurst           int
+		vaultMaxRetries      int
+		breakerThreshold     int
+		breakerCooldown      time.Duration
+		tracingOpts          tracing.Options
 	)
 	flag.StringVar(&vaultAddr, "vault-addr", "", "The address the vault client will connect to.")
 	flag.StringVar(&vaultRoleID, "vault-role-id", "", "AppRole RoleID used to connect to vault.")
@@ -66,6 +82,27 @@ func main() {
 	flag.StringVar(&vaultNamespace, "vault-namespace", "", "The Vault namespace the operator works with.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&generatorPluginDir, "generator-plugin-dir", "",
+		"Directory containing the executables of exec generators. Exec generators are disabled if empty.")
+	flag.DurationVar(&vaultCacheTTL, "vault-cache-ttl", 0,
+		"Duration reads from vault are cached across reconciles. Caching is disabled if zero.")
+	flag.DurationVar(&healthCacheTTL, "vault-health-cache-ttl", 10*time.Second,
+		"Duration the result of checking the health of vault and the token is reused by the probes.")
+	flag.DurationVar(&unavailableThreshold, "vault-unavailable-threshold", time.Minute,
+		"Duration vault may be unreachable or reject the token before the operator is reported as not ready.")
+	flag.Float64Var(&vaultQPS, "vault-qps", 20, "Maximum number of requests per second sent to vault, unlimited if zero.")
+	flag.IntVar(&vaultBurst, "vault-burst", 40, "Maximum burst of requests sent to vault above the QPS limit.")
+	flag.IntVar(&vaultMaxRetries, "vault-max-retries", 3,
+		"Number of times requests failing with a 5xx or 429 response or a connection error are retried with backoff.")
+	flag.IntVar(&breakerThreshold, "vault-breaker-threshold", 5,
+		"Number of requests in a row vault may fail before requests are stopped for the cooldown. Disabled if zero.")
+	flag.DurationVar(&breakerCooldown, "vault-breaker-cooldown", 30*time.Second,
+		"Duration requests to vault are stopped once the breaker threshold is reached, reconciles are requeued after it.")
+	flag.StringVar(&tracingOpts.Endpoint, "otlp-endpoint", "",
+		"Host and port of the OTLP/HTTP collector traces are exported to. Tracing is disabled if empty.")
+	flag.BoolVar(&tracingOpts.Insecure, "otlp-insecure", false, "Export traces without TLS.")
+	flag.Float64Var(&tracingOpts.SampleRatio, "trace-sample-ratio", 1,
+		"Fraction of reconciles which are traced, requests with a sampled parent span are always traced.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -77,6 +114,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOpts)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// Check if vault credentials were provided via env variables and make sure
 	// mandatory variables are provided
 	if vaultAddr == "" {
@@ -112,11 +155,17 @@ func main() {
 		setupLog.Error(errors.New("no valid configuration for authentication provided"), "token or approle missing")
 		os.Exit(2)
 	}
-	vc, err := vault.NewClient(vaultAddr, vaultNamespace, authMethod)
+	// The token of the vault client is renewed until the operator is stopped
+	ctx := ctrl.SetupSignalHandler()
+	vc, err := vault.NewClient(ctx, vaultAddr, vaultNamespace, authMethod)
 	if err != nil {
 		setupLog.Error(err, "unable to create vault client")
 		os.Exit(1)
 	}
+	vc.EnableCache(vaultCacheTTL)
+	vc.EnableRateLimit(vaultQPS, vaultBurst)
+	vc.SetMaxRetries(vaultMaxRetries)
+	vc.EnableCircuitBreaker(breakerThreshold, breakerCooldown)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -131,33 +180,78 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Generators compiled into the operator can be added to the registry of built-in generators
+	generators := generator.NewRegistry()
 	if err = (&controllers.VaultSecretReconciler{
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Log:                ctrl.Log.WithName("controllers").WithName("VaultSecret"),
+		Vault:              vc,
+		Generators:         generators,
+		GeneratorPluginDir: generatorPluginDir,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VaultSecret")
+		os.Exit(1)
+	}
+	if err = (&controllers.VaultSecretImportReconciler{
 		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Log:    ctrl.Log.WithName("controllers").WithName("VaultSecret"),
+		Log:    ctrl.Log.WithName("controllers").WithName("VaultSecretImport"),
 		Vault:  vc,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "VaultSecret")
+		setupLog.Error(err, "unable to create controller", "controller", "VaultSecretImport")
 		os.Exit(1)
 	}
 
-	if err = (&vaultv1alpha1.VaultSecret{}).SetupWebhookWithManager(mgr); err != nil {
+	if err = (&vaultv1alpha1.VaultSecret{}).SetupWebhookWithManager(mgr, webhookVaultReader{vc}); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "VaultSecret")
 	}
+	if err = (&vaultv1alpha1.VaultSecretGenerator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "VaultSecretGenerator")
+	}
+	mgr.GetWebhookServer().Register("/mutate-v1-pod", &webhook.Admission{Handler: &injector.PodInjector{
+		Vault: vc,
+		Log:   ctrl.Log.WithName("webhooks").WithName("PodInjector"),
+	}})
 	//+kubebuilder:scaffold:builder
 
+	vaultHealth := vc.NewHealthChecker(healthCacheTTL, unavailableThreshold)
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddHealthzCheck("vault-token", vaultHealth.Live); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("vault", vaultHealth.Ready); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	vc.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "flushing traces failed")
+	}
+}
+
+// webhookVaultReader adapts the vault client to the reader of the VaultSecret webhook, which does not depend on
+// the vault package.
+type webhookVaultReader struct {
+	*vault.Client
+}
+
+func (r webhookVaultReader) GetAll(ctx context.Context, path string, version int) (map[string]string, error) {
+	fields, err := r.Client.GetAll(ctx, path, version)
+	if errors.Is(err, vault.ErrNotFound) {
+		return nil, vaultv1alpha1.ErrVaultEntryNotFound
+	}
+	return fields, err
 }
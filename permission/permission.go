@@ -0,0 +1,66 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package permission implements the simplified access control of vault paths, which is shared by the
+// VaultSecret controller and the pod injector.
+package permission
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/finleap-connect/vaultoperator/util"
+)
+
+var (
+	ErrInvalidVaultPath = errors.New("invalid vault path, shoud contain at least 3 segments")
+	ErrPermissionDenied = errors.New("permission denied by VaultOperator")
+)
+
+// Check returns nil if objects in the namespace may access the vault path. Paths of the engines allowed by
+// ALLOWED_ENGINES have to be scoped by the namespace or one of the SHARED_PATHS, e.g. app/<namespace>/<key>.
+// Paths of the cert engine are always accessible.
+func Check(namespace, vaultPath string) error {
+	// TODO: we should implement CRDs to control permissions to vault secrets! SUPER IMPORTANT TO REMOVE THIS MADNESS!
+	segments := strings.Split(strings.Trim(vaultPath, "/"), "/")
+	if len(segments) < 1 {
+		return ErrInvalidVaultPath
+	}
+
+	allowedEngines := strings.Split(os.Getenv("ALLOWED_ENGINES"), ",")
+
+	firstSegment := segments[0]
+	if firstSegment == "cert" {
+		return nil
+	}
+	if util.ContainsString(allowedEngines, firstSegment) {
+		// The Vault path should be scoped (e.g. <allowed-engine>/<namespace>/<key-name>) and thus consist
+		// of at least 3 parts.
+		if len(segments) < 3 {
+			return ErrInvalidVaultPath
+		}
+
+		// The scope-part should either match the namespace or some pre-defined shared identifiers.
+		secondSegment := segments[1]
+		sharedPaths := strings.Split(os.Getenv("SHARED_PATHS"), ",")
+		switch {
+		case secondSegment == namespace:
+			return nil
+		case util.ContainsString(sharedPaths, secondSegment):
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
@@ -15,47 +15,144 @@
 package util
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
-func GenerateRSA(bits int32) ([]byte, error) {
-	pk, err := rsa.GenerateKey(rand.Reader, int(bits))
-	if err != nil {
-		return nil, err
+const (
+	// PKCS #1 encoding of RSA private keys
+	FormatPKCS1 = "PKCS1"
+	// SEC 1 encoding of EC private keys
+	FormatSEC1 = "SEC1"
+	// PKCS #8 encoding of any private key
+	FormatPKCS8 = "PKCS8"
+)
+
+// GetCurve returns the elliptic curve with the given name, e.g. "P-256".
+func GetCurve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-224":
+		return elliptic.P224(), nil
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, errors.New("unknown curve")
 	}
-	x509Enc := x509.MarshalPKCS1PrivateKey(pk)
-	pemEnc := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509Enc})
-	return pemEnc, nil
 }
 
-func GenerateECDSA(c int32) ([]byte, error) {
-	var curve elliptic.Curve
-	switch c {
-	case 224:
-		curve = elliptic.P224()
-	case 256:
-		curve = elliptic.P256()
-	case 384:
-		curve = elliptic.P384()
-	case 521:
-		curve = elliptic.P521()
+// GenerateKey generates a private key of the given type ("rsa", "ecdsa" or "ed25519"). The bits are
+// only used for rsa keys and the curve only for ecdsa keys.
+func GenerateKey(keyType string, bits int, curveName string) (crypto.Signer, error) {
+	switch keyType {
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, bits)
+	case "ecdsa":
+		curve, err := GetCurve(curveName)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case "ed25519":
+		_, pk, err := ed25519.GenerateKey(rand.Reader)
+		return pk, err
 	default:
-		return nil, errors.New("unknown curve")
+		return nil, errors.New("unknown key type")
+	}
+}
+
+// MarshalPrivateKey encodes the private key as PEM in the given format.
+func MarshalPrivateKey(key crypto.Signer, format string) ([]byte, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if format == FormatPKCS1 {
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+		}
+	case *ecdsa.PrivateKey:
+		if format == FormatSEC1 {
+			der, err := x509.MarshalECPrivateKey(k)
+			if err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		}
+	}
+	if block == nil {
+		if format != FormatPKCS8 {
+			return nil, errors.New("unsupported private key format")
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPublicKey encodes the public key as PKIX PEM.
+func MarshalPublicKey(key crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
 	}
-	pk, err := ecdsa.GenerateKey(curve, rand.Reader)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// GenerateCertificate creates a self-signed X.509 certificate for the given key encoded as PEM.
+func GenerateCertificate(key crypto.Signer, commonName string, dnsNames []string, ipAddresses []net.IP, validity time.Duration, isCA bool) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
 		return nil, err
 	}
-	x509Enc, err := x509.MarshalECPrivateKey(pk)
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		IPAddresses:           ipAddresses,
+		NotBefore:             now.Add(-5 * time.Minute), // tolerate clock skew
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
 	if err != nil {
 		return nil, err
 	}
-	pemEnc := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: x509Enc})
-	return pemEnc, nil
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// HashBcrypt hashes the password with bcrypt using the given cost.
+func HashBcrypt(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
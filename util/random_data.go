@@ -15,30 +15,147 @@
 package util
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
 
 	"github.com/sethvargo/go-password/password"
 )
 
-const allowedGlyphs = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.,/?!@#$%^&*()_+}|\":;'\\][=-"
+// DefaultAlphabet is the alphabet of the string generator if none is configured.
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.,/?!@#$%^&*()_+}|\":;'\\][=-"
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
+var (
+	ErrAlphabetTooShort    = errors.New("alphabet must contain at least two characters")
+	ErrAlphabetNotUnique   = errors.New("alphabet must not contain duplicate characters")
+	ErrInvalidRandomLength = errors.New("length must not be negative")
+	ErrPasswordExcluded    = errors.New("password excludes all digits or symbols")
+)
 
-func RandBytes(n int) []byte {
+// RandBytes returns n bytes read from crypto/rand.
+func RandBytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, ErrInvalidRandomLength
+	}
 	b := make([]byte, n)
-	for i := range b {
-		b[i] = allowedGlyphs[rand.Intn(len(allowedGlyphs))]
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes: %w", err)
 	}
-	return b
+	return b, nil
 }
 
-func RandString(n int) string {
-	return string(RandBytes(n))
+// RandStringFrom returns a string of n characters chosen uniformly from the given alphabet.
+func RandStringFrom(n int, alphabet string) (string, error) {
+	return randString(rand.Reader, n, alphabet)
+}
+
+// ValidateAlphabet checks that the alphabet contains at least two distinct characters and no duplicates.
+func ValidateAlphabet(alphabet string) error {
+	chars := []rune(alphabet)
+	if len(chars) < 2 {
+		return ErrAlphabetTooShort
+	}
+	seen := make(map[rune]struct{}, len(chars))
+	for _, c := range chars {
+		if _, ok := seen[c]; ok {
+			return ErrAlphabetNotUnique
+		}
+		seen[c] = struct{}{}
+	}
+	return nil
 }
 
-func RandPassword(n, digits, symbols int) (string, error) {
-	return password.Generate(n, digits, symbols, false, true)
+// Entropy returns the entropy in bits of a string of n characters chosen uniformly from the given alphabet.
+func Entropy(n int, alphabet string) float64 {
+	size := len([]rune(alphabet))
+	if n <= 0 || size < 2 {
+		return 0
+	}
+	return float64(n) * math.Log2(float64(size))
+}
+
+// randString samples characters with rejection sampling, so every character of the alphabet is
+// equally likely regardless of the size of the alphabet.
+func randString(r io.Reader, n int, alphabet string) (string, error) {
+	if n < 0 {
+		return "", ErrInvalidRandomLength
+	}
+	if err := ValidateAlphabet(alphabet); err != nil {
+		return "", err
+	}
+	chars := []rune(alphabet)
+	result := make([]rune, 0, n)
+	if len(chars) > 256 {
+		max := big.NewInt(int64(len(chars)))
+		for len(result) < n {
+			i, err := rand.Int(r, max)
+			if err != nil {
+				return "", fmt.Errorf("failed to read random bytes: %w", err)
+			}
+			result = append(result, chars[i.Int64()])
+		}
+		return string(result), nil
+	}
+
+	// Bytes at or above limit are rejected as they would favour the first characters of the alphabet
+	limit := 256 - 256%len(chars)
+	buf := make([]byte, n)
+	for len(result) < n {
+		if _, err := io.ReadFull(r, buf[:n-len(result)]); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		for _, b := range buf[:n-len(result)] {
+			if int(b) < limit {
+				result = append(result, chars[int(b)%len(chars)])
+			}
+		}
+	}
+	return string(result), nil
+}
+
+// PasswordAlphabets returns the letters, digits and symbols a password is generated from, without the excluded
+// characters. If charset is not empty the symbols are chosen from it.
+func PasswordAlphabets(noUpper bool, charset, exclude string) (letters, digits, symbols string) {
+	letters = password.LowerLetters
+	if !noUpper {
+		letters += password.UpperLetters
+	}
+	if charset == "" {
+		charset = password.Symbols
+	}
+	keep := func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}
+	return strings.Map(keep, letters), strings.Map(keep, password.Digits), strings.Map(keep, charset)
+}
+
+// GeneratePassword generates a password of the given length containing the given number of digits and
+// symbols. If charset is not empty the symbols are chosen from it, characters contained in exclude are never used.
+func GeneratePassword(n, digits, symbols int, noUpper, allowRepeat bool, charset, exclude string) (string, error) {
+	letters, digitChars, symbolChars := PasswordAlphabets(noUpper, charset, exclude)
+	if n-digits-symbols > 0 {
+		if err := ValidateAlphabet(letters); err != nil {
+			return "", fmt.Errorf("invalid password letters: %w", err)
+		}
+	}
+	if (digits > 0 && digitChars == "") || (symbols > 0 && symbolChars == "") {
+		return "", ErrPasswordExcluded
+	}
+	// Empty inputs fall back to the defaults of the generator, so letters are passed as lower case letters only
+	gen, err := password.NewGenerator(&password.GeneratorInput{
+		LowerLetters: letters,
+		Digits:       digitChars,
+		Symbols:      symbolChars,
+	})
+	if err != nil {
+		return "", err
+	}
+	return gen.Generate(n, digits, symbols, true, allowRepeat)
 }
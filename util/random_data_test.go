@@ -0,0 +1,171 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// chiSquare returns the chi-square statistic of the observed counts against a uniform distribution.
+func chiSquare(counts map[rune]int, categories, samples int) float64 {
+	expected := float64(samples) / float64(categories)
+	var stat float64
+	for _, c := range counts {
+		d := float64(c) - expected
+		stat += d * d / expected
+	}
+	// categories which were never observed
+	stat += float64(categories-len(counts)) * expected
+	return stat
+}
+
+// chiSquareLimit approximates the critical value of the chi-square distribution with the given degrees of
+// freedom at a significance level of about 1e-6 (Wilson-Hilferty with z=4.75).
+func chiSquareLimit(df int) float64 {
+	k := float64(df)
+	z := 4.75
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+func TestRandStringFromIsUniform(t *testing.T) {
+	for _, alphabet := range []string{
+		"01",
+		"abc",
+		"0123456789abcdef",
+		DefaultAlphabet,
+		"äöüß€",
+	} {
+		const samples = 200000
+		s, err := RandStringFrom(samples, alphabet)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		counts := map[rune]int{}
+		n := 0
+		for _, c := range s {
+			if !strings.ContainsRune(alphabet, c) {
+				t.Fatalf("character %q is not part of the alphabet %q", c, alphabet)
+			}
+			counts[c]++
+			n++
+		}
+		if n != samples {
+			t.Fatalf("expected %d characters, got %d", samples, n)
+		}
+		categories := len([]rune(alphabet))
+		if stat, limit := chiSquare(counts, categories, samples), chiSquareLimit(categories-1); stat > limit {
+			t.Errorf("distribution over %q is not uniform: chi-square %.2f exceeds %.2f", alphabet, stat, limit)
+		}
+	}
+}
+
+func TestRandBytesIsUniform(t *testing.T) {
+	const samples = 512000
+	b, err := RandBytes(samples)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts := map[rune]int{}
+	for _, v := range b {
+		counts[rune(v)]++
+	}
+	if stat, limit := chiSquare(counts, 256, samples), chiSquareLimit(255); stat > limit {
+		t.Errorf("distribution of bytes is not uniform: chi-square %.2f exceeds %.2f", stat, limit)
+	}
+}
+
+func TestRandStringRejectsBiasedBytes(t *testing.T) {
+	// With three characters the byte 255 would favour the first character and has to be skipped
+	r := bytes.NewReader([]byte{255, 0, 255, 1, 2})
+	s, err := randString(r, 3, "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s != "abc" {
+		t.Errorf("expected abc, got %s", s)
+	}
+}
+
+func TestRandStringFromInvalidAlphabet(t *testing.T) {
+	for alphabet, expected := range map[string]error{
+		"":    ErrAlphabetTooShort,
+		"a":   ErrAlphabetTooShort,
+		"aba": ErrAlphabetNotUnique,
+	} {
+		if _, err := RandStringFrom(8, alphabet); !errors.Is(err, expected) {
+			t.Errorf("expected %v for alphabet %q, got %v", expected, alphabet, err)
+		}
+	}
+	if _, err := RandStringFrom(-1, "ab"); !errors.Is(err, ErrInvalidRandomLength) {
+		t.Errorf("expected %v, got %v", ErrInvalidRandomLength, err)
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	for _, test := range []struct {
+		n        int
+		alphabet string
+		bits     float64
+	}{
+		{n: 32, alphabet: "01", bits: 32},
+		{n: 10, alphabet: "0123456789abcdef", bits: 40},
+		{n: 0, alphabet: "01", bits: 0},
+		{n: 8, alphabet: "a", bits: 0},
+	} {
+		if bits := Entropy(test.n, test.alphabet); math.Abs(bits-test.bits) > 1e-9 {
+			t.Errorf("expected %v bits for %d characters of %q, got %v", test.bits, test.n, test.alphabet, bits)
+		}
+	}
+}
+
+func TestGeneratePasswordExcludesCharacters(t *testing.T) {
+	const exclude = "0O1lI|"
+	for i := 0; i < 100; i++ {
+		p, err := GeneratePassword(32, 8, 8, false, true, "", exclude)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(p) != 32 {
+			t.Fatalf("expected length 32, got %q", p)
+		}
+		if strings.ContainsAny(p, exclude) {
+			t.Fatalf("password %q contains excluded characters", p)
+		}
+	}
+}
+
+func TestGeneratePasswordExcludesAllCharacters(t *testing.T) {
+	if _, err := GeneratePassword(8, 2, 0, false, true, "", "0123456789"); !errors.Is(err, ErrPasswordExcluded) {
+		t.Errorf("expected %v, got %v", ErrPasswordExcluded, err)
+	}
+	if _, err := GeneratePassword(8, 0, 2, false, true, "#", "#"); !errors.Is(err, ErrPasswordExcluded) {
+		t.Errorf("expected %v, got %v", ErrPasswordExcluded, err)
+	}
+	upper := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	p, err := GeneratePassword(16, 0, 0, false, true, "", upper)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.ContainsAny(p, upper) {
+		t.Errorf("password %q contains excluded upper case letters", p)
+	}
+	if _, err := GeneratePassword(8, 0, 0, true, true, "", "abcdefghijklmnopqrstuvwxy"); !errors.Is(err, ErrAlphabetTooShort) {
+		t.Errorf("expected %v, got %v", ErrAlphabetTooShort, err)
+	}
+}
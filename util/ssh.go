@@ -0,0 +1,104 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const openSSHMagic = "openssh-key-v1\x00"
+
+// MarshalSSHKeyPair encodes the private key in the OpenSSH private key format and its public key in
+// the authorized_keys format.
+func MarshalSSHKeyPair(key crypto.Signer, comment string) (private []byte, authorized []byte, err error) {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+	var keyFields []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		k.Precompute()
+		keyFields = ssh.Marshal(struct {
+			N, E, D, Iqmp, P, Q *big.Int
+		}{k.N, big.NewInt(int64(k.E)), k.D, k.Precomputed.Qinv, k.Primes[0], k.Primes[1]})
+	case *ecdsa.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			Curve string
+			Pub   []byte
+			D     *big.Int
+		}{sshCurveName(k.Curve), elliptic.Marshal(k.Curve, k.X, k.Y), k.D})
+	case ed25519.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			Pub, Priv []byte
+		}{k.Public().(ed25519.PublicKey), k})
+	default:
+		return nil, nil, errors.New("unsupported ssh key type")
+	}
+
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+	block := ssh.Marshal(struct {
+		Check1, Check2 uint32
+		KeyType        string
+		Rest           []byte `ssh:"rest"`
+	}{checkInt, checkInt, pub.Type(), keyFields})
+	block = append(block, ssh.Marshal(struct{ Comment string }{comment})...)
+	for i := byte(1); len(block)%8 != 0; i++ {
+		block = append(block, i)
+	}
+
+	encoded := ssh.Marshal(struct {
+		CipherName, KdfName, KdfOpts string
+		NumKeys                      uint32
+		PubKey, PrivKeyBlock         []byte
+	}{"none", "none", "", 1, pub.Marshal(), block})
+	private = pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: append([]byte(openSSHMagic), encoded...)})
+
+	authorized = bytes.TrimSuffix(ssh.MarshalAuthorizedKey(pub), []byte("\n"))
+	if comment != "" {
+		authorized = append(authorized, []byte(" "+comment)...)
+	}
+	return private, authorized, nil
+}
+
+func sshCurveName(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "nistp256"
+	case elliptic.P384():
+		return "nistp384"
+	case elliptic.P521():
+		return "nistp521"
+	}
+	return ""
+}
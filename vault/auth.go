@@ -17,13 +17,15 @@ package vault
 // Initial version from: authn-authz/customer-credential-service/blob/develop/pkg/vault/vault.go
 
 import (
+	"context"
+
 	"github.com/hashicorp/vault/api"
 )
 
 // AuthMethod specifies an authentication method for the Hashicorp Vault API.
 type AuthMethod interface {
 	// Login creates a new authentication token.
-	Login(*Client) (*api.Secret, error)
+	Login(context.Context, *Client) (*api.Secret, error)
 	// Name returns the name of the authentication method.
 	Name() string
 	// Check if token is renewable
@@ -37,8 +39,8 @@ type AppRoleAuth struct {
 	SecretID string
 }
 
-func (a *AppRoleAuth) Login(c *Client) (*api.Secret, error) {
-	return c.Logical().Write("/auth/approle/login", map[string]interface{}{
+func (a *AppRoleAuth) Login(ctx context.Context, c *Client) (*api.Secret, error) {
+	return c.Logical().WriteWithContext(ctx, "/auth/approle/login", map[string]interface{}{
 		"role_id":   a.RoleID,
 		"secret_id": a.SecretID,
 	})
@@ -52,7 +54,7 @@ type TokenAuth struct {
 	Token string
 }
 
-func (a *TokenAuth) Login(c *Client) (*api.Secret, error) {
+func (a *TokenAuth) Login(_ context.Context, c *Client) (*api.Secret, error) {
 	return &api.Secret{Auth: &api.SecretAuth{ClientToken: a.Token}}, nil
 }
 
@@ -0,0 +1,140 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// UnavailableError is returned instead of sending a request while the circuit breaker is open, because
+// vault failed too many requests in a row.
+type UnavailableError struct {
+	// RetryAfter is the time until the breaker lets a request through again.
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%v, retrying in %s", ErrUnavailable, e.RetryAfter.Round(time.Second))
+}
+
+func (e *UnavailableError) Is(target error) bool { return target == ErrUnavailable }
+
+// circuitBreaker stops requests to vault once threshold requests in a row failed. After the cooldown a
+// single request is let through to probe vault, which closes the breaker if it succeeds. The breaker is
+// disabled if threshold is zero.
+type circuitBreaker struct {
+	log logr.Logger
+	now func() time.Time
+
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) configure(threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.cooldown = cooldown
+}
+
+// isOpen returns whether requests are currently stopped, the caller holds the lock.
+func (b *circuitBreaker) isOpen() bool {
+	return b.threshold > 0 && b.failures >= b.threshold
+}
+
+// allow returns an UnavailableError if the breaker is open and no request may probe vault.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.isOpen() {
+		return nil
+	}
+	now := b.now()
+	if now.Before(b.openUntil) {
+		return &UnavailableError{RetryAfter: b.openUntil.Sub(now)}
+	}
+	if b.probing {
+		return &UnavailableError{RetryAfter: b.cooldown}
+	}
+	b.probing = true
+	return nil
+}
+
+// record records the result of a request which was allowed.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		if b.isOpen() {
+			b.log.Info("vault is available again, resuming requests")
+			circuitOpen.Set(0)
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.isOpen() {
+		if b.failures == b.threshold {
+			b.log.Info("vault is unavailable, pausing requests", "failures", b.failures, "cooldown", b.cooldown)
+			circuitOpen.Set(1)
+		}
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// abort records that a request which was allowed was canceled by the caller before vault answered.
+func (b *circuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// breakerTransport sends requests through the circuit breaker. Failed connections, 5xx responses other
+// than 501 Not Implemented and 429 Too Many Requests count as failures.
+type breakerTransport struct {
+	base    http.RoundTripper
+	breaker *circuitBreaker
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if req.Context().Err() != nil {
+		t.breaker.abort()
+	} else {
+		t.breaker.record(isFailure(resp, err))
+	}
+	return resp, err
+}
+
+func isFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented
+}
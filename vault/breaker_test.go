@@ -0,0 +1,147 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := &circuitBreaker{log: logr.Discard(), now: func() time.Time { return now }}
+	b.configure(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("expected request %d to be allowed, got %v", i, err)
+		}
+		b.record(true)
+	}
+	err := b.allow()
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected breaker to be open, got %v", err)
+	}
+	if unavailable.RetryAfter != time.Minute {
+		t.Errorf("expected retry after the cooldown, got %s", unavailable.RetryAfter)
+	}
+
+	// A single request probes vault after the cooldown
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected only one probe, got %v", err)
+	}
+	b.record(true)
+	if err := b.allow(); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected failed probe to open the breaker again, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	b.record(false)
+	if err := b.allow(); err != nil {
+		t.Fatalf("expected successful probe to close the breaker, got %v", err)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := &circuitBreaker{log: logr.Discard(), now: time.Now}
+	for i := 0; i < 10; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatal(err)
+		}
+		b.record(true)
+	}
+}
+
+func TestBreakerTransport(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	b := &circuitBreaker{log: logr.Discard(), now: time.Now}
+	b.configure(3, time.Minute)
+	client := &http.Client{Transport: &breakerTransport{base: http.DefaultTransport, breaker: b}}
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		if i < 3 {
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		} else if !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected request %d to be stopped, got %v", i, err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("expected requests to stop after the threshold, vault got %d", requests)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		code  int
+		retry bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusForbidden, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusNotImplemented, false},
+		{http.StatusServiceUnavailable, true},
+	} {
+		retry, err := retryPolicy(ctx, &http.Response{StatusCode: tc.code}, nil)
+		if err != nil || retry != tc.retry {
+			t.Errorf("expected retry of %d to be %v, got %v, %v", tc.code, tc.retry, retry, err)
+		}
+	}
+	if retry, _ := retryPolicy(ctx, nil, errors.Wrap(&UnavailableError{}, "Get")); retry {
+		t.Error("expected requests stopped by the breaker not to be retried")
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	min, max := 100*time.Millisecond, time.Second
+	for attempt := 0; attempt < 40; attempt++ {
+		if wait := retryBackoff(min, max, attempt, nil); wait < min || wait > max {
+			t.Errorf("expected wait of attempt %d between %s and %s, got %s", attempt, min, max, wait)
+		}
+	}
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"1"}}}
+	if wait := retryBackoff(min, 2*time.Second, 0, throttled); wait != time.Second {
+		t.Errorf("expected Retry-After to be honored, got %s", wait)
+	}
+	throttled.Header.Set("Retry-After", "60")
+	if wait := retryBackoff(min, max, 0, throttled); wait != max {
+		t.Errorf("expected Retry-After to be capped, got %s", wait)
+	}
+}
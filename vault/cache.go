@@ -0,0 +1,169 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheKey identifies a read of an entry. Version zero refers to the latest version.
+type cacheKey struct {
+	namespace string
+	path      string
+	version   int
+}
+
+// cachedEntry holds the fields of an entry, nil if it does not exist.
+type cachedEntry struct {
+	fields  map[string]string
+	expires time.Time
+}
+
+// ReadCache deduplicates reads of entries. It is meant to be used for a single reconcile, so it never
+// expires and is not safe for concurrent use.
+type ReadCache struct {
+	client  *Client
+	entries map[cacheKey]map[string]string
+}
+
+// NewReadCache returns an empty read cache reading through the client.
+func (c *Client) NewReadCache() *ReadCache {
+	return &ReadCache{client: c, entries: map[cacheKey]map[string]string{}}
+}
+
+// GetAll returns all fields of the entry at path like Client.GetAll, reading it only once.
+func (r *ReadCache) GetAll(ctx context.Context, path string, version int) (map[string]string, error) {
+	key := r.client.cacheKey(path, version)
+	fields, ok := r.entries[key]
+	if !ok {
+		var err error
+		fields, err = r.client.GetAll(ctx, path, version)
+		if err == ErrNotFound {
+			fields = nil
+		} else if err != nil {
+			return nil, err
+		}
+		r.entries[key] = fields
+	}
+	if fields == nil {
+		return nil, ErrNotFound
+	}
+	return fields, nil
+}
+
+// Get returns a field of the entry at path like Client.Get, reading the entry only once.
+func (r *ReadCache) Get(ctx context.Context, path, field string, version int) (string, error) {
+	fields, err := r.GetAll(ctx, path, version)
+	if err != nil {
+		return "", err
+	}
+	value, ok := fields[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// SetLatest records the fields of the latest version of the entry at path, e.g. after it was written.
+func (r *ReadCache) SetLatest(path string, fields map[string]string) {
+	r.entries[r.client.cacheKey(path, 0)] = copyFields(fields)
+}
+
+// Invalidate drops all versions of the entry at path, so it is read again.
+func (r *ReadCache) Invalidate(path string) {
+	path = strings.Trim(path, "/")
+	for key := range r.entries {
+		if key.path == path {
+			delete(r.entries, key)
+		}
+	}
+}
+
+// ttlCache is shared by all reconciles of a client. Entries written by the client are invalidated. Expired
+// entries are dropped when read and swept at most once per ttl when entries are added, so entries which are
+// never read again do not accumulate.
+type ttlCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[cacheKey]cachedEntry
+	sweepAt time.Time
+}
+
+func (t *ttlCache) get(key cacheKey, now time.Time) (map[string]string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expires) {
+		delete(t.entries, key)
+		return nil, false
+	}
+	return entry.fields, true
+}
+
+func (t *ttlCache) set(key cacheKey, fields map[string]string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !now.Before(t.sweepAt) {
+		t.sweep(now)
+	}
+	t.entries[key] = cachedEntry{fields: fields, expires: now.Add(t.ttl)}
+}
+
+// sweep drops all expired entries, the lock has to be held.
+func (t *ttlCache) sweep(now time.Time) {
+	for key, entry := range t.entries {
+		if !now.Before(entry.expires) {
+			delete(t.entries, key)
+		}
+	}
+	t.sweepAt = now.Add(t.ttl)
+}
+
+func (t *ttlCache) invalidate(namespace, path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.entries {
+		if key.namespace == namespace && key.path == path {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// EnableCache caches reads of the client for the given duration. Entries written through the client
+// are invalidated immediately, changes made by others are only visible once the cached read expired.
+func (c *Client) EnableCache(ttl time.Duration) {
+	if ttl <= 0 {
+		c.cache = nil
+		return
+	}
+	c.cache = &ttlCache{ttl: ttl, entries: map[cacheKey]cachedEntry{}}
+}
+
+// InvalidateCache drops the cached reads of all versions of the entry at path, so it is read from vault again.
+func (c *Client) InvalidateCache(path string) {
+	if c.cache != nil {
+		c.cache.invalidate(c.Namespace(), strings.Trim(path, "/"))
+	}
+}
+
+func (c *Client) cacheKey(path string, version int) cacheKey {
+	return cacheKey{namespace: c.Namespace(), path: strings.Trim(path, "/"), version: version}
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheExpires(t *testing.T) {
+	cache := &ttlCache{ttl: time.Minute, entries: map[cacheKey]cachedEntry{}}
+	key := cacheKey{path: "a/b"}
+	now := time.Now()
+	cache.set(key, map[string]string{"f": "v"}, now)
+
+	if fields, ok := cache.get(key, now.Add(30*time.Second)); !ok || fields["f"] != "v" {
+		t.Fatalf("expected cached entry, got %v, %v", fields, ok)
+	}
+	if _, ok := cache.get(key, now.Add(time.Minute)); ok {
+		t.Fatal("expected entry to be expired")
+	}
+	if len(cache.entries) != 0 {
+		t.Fatalf("expected expired entry to be dropped, got %v", cache.entries)
+	}
+}
+
+func TestTTLCacheSweepsExpiredEntries(t *testing.T) {
+	cache := &ttlCache{ttl: time.Minute, entries: map[cacheKey]cachedEntry{}}
+	now := time.Now()
+	for _, path := range []string{"a/b", "a/c", "a/d"} {
+		cache.set(cacheKey{path: path}, map[string]string{}, now)
+	}
+
+	// Entries are swept at most once per ttl
+	cache.set(cacheKey{path: "b/a"}, map[string]string{}, now.Add(30*time.Second))
+	if len(cache.entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(cache.entries))
+	}
+
+	cache.set(cacheKey{path: "b/b"}, map[string]string{}, now.Add(time.Minute))
+	if len(cache.entries) != 2 {
+		t.Fatalf("expected expired entries to be swept, got %v", cache.entries)
+	}
+	for _, path := range []string{"b/a", "b/b"} {
+		if _, ok := cache.entries[cacheKey{path: path}]; !ok {
+			t.Errorf("expected %s to be cached", path)
+		}
+	}
+}
+
+func TestTTLCacheCachesMissingEntries(t *testing.T) {
+	cache := &ttlCache{ttl: time.Minute, entries: map[cacheKey]cachedEntry{}}
+	key := cacheKey{path: "a/b"}
+	now := time.Now()
+	cache.set(key, nil, now)
+
+	if fields, ok := cache.get(key, now); !ok || fields != nil {
+		t.Fatalf("expected cached missing entry, got %v, %v", fields, ok)
+	}
+}
+
+func TestTTLCacheInvalidate(t *testing.T) {
+	cache := &ttlCache{ttl: time.Minute, entries: map[cacheKey]cachedEntry{}}
+	now := time.Now()
+	keys := []cacheKey{
+		{namespace: "ns", path: "a/b"},
+		{namespace: "ns", path: "a/b", version: 2},
+		{namespace: "other", path: "a/b"},
+		{namespace: "ns", path: "a/c"},
+	}
+	for _, key := range keys {
+		cache.set(key, map[string]string{}, now)
+	}
+
+	cache.invalidate("ns", "a/b")
+
+	for i, key := range keys {
+		_, ok := cache.get(key, now)
+		if want := i >= 2; ok != want {
+			t.Errorf("key %v: expected cached %v, got %v", key, want, ok)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -25,22 +26,42 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/vault/api"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/finleap-connect/vaultoperator/tracing"
 )
 
-const initialTokenTimeout = 10 * time.Second
+const (
+	initialTokenTimeout = 10 * time.Second
+	// maxCASRetries limits how often a conditional write is retried if the entry was modified concurrently.
+	maxCASRetries = 5
+)
 
 type Client struct {
 	*api.Client
 	log          logr.Logger
 	tokenHandler *TokenHandler
+	cache        *ttlCache
+	breaker      *circuitBreaker
 }
 
-func NewClient(addr, namespace string, method AuthMethod) (*Client, error) {
+// NewClient returns a client of the vault at addr authenticated with the auth method. Tokens are renewed
+// until the context is done or the client is closed.
+func NewClient(ctx context.Context, addr, namespace string, method AuthMethod) (*Client, error) {
 	var err error
 	c := &Client{log: ctrl.Log.WithName("VaultClient")}
+	c.breaker = &circuitBreaker{log: c.log, now: time.Now}
 	cfg := api.DefaultConfig()
 	cfg.Address = addr
+	cfg.CheckRetry = retryPolicy
+	cfg.Backoff = retryBackoff
+	cfg.MinRetryWait = minRetryWait
+	cfg.MaxRetryWait = maxRetryWait
+	// Propagate the trace context to vault, so requests show up in its audit log with the trace ID
+	base := &breakerTransport{base: cfg.HttpClient.Transport, breaker: c.breaker}
+	cfg.HttpClient.Transport = otelhttp.NewTransport(&forbiddenHook{base: base, onForbidden: c.relogin})
 	c.Client, err = api.NewClient(cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create vault client")
@@ -50,7 +71,8 @@ func NewClient(addr, namespace string, method AuthMethod) (*Client, error) {
 	}
 	if method != nil {
 		c.log.Info("Configure auth method.", "name", method.Name())
-		c.tokenHandler = NewTokenHandler(c, method)
+		c.tokenHandler = NewTokenHandler(ctx, c, method)
+		c.tokenHandler.Start()
 		if err := c.tokenHandler.WaitForToken(initialTokenTimeout); err != nil {
 			return nil, err
 		}
@@ -64,54 +86,221 @@ func NewClient(addr, namespace string, method AuthMethod) (*Client, error) {
 	return c, nil
 }
 
-func (c *Client) GetAll(path string, version int) (map[string]string, error) {
-	path = toDataPath(path)
+func (c *Client) GetAll(ctx context.Context, path string, version int) (map[string]string, error) {
+	return c.getAll(ctx, "GetAll", path, version)
+}
+
+// getAll reads the fields of the entry at path, requests to vault are recorded as the operation.
+func (c *Client) getAll(ctx context.Context, operation, path string, version int) (_ map[string]string, err error) {
+	ctx, span := startSpan(ctx, operation, path, version)
+	defer func() { tracing.End(span, ignoreNotFound(err)) }()
+
+	key := c.cacheKey(path, version)
+	if c.cache != nil {
+		if fields, ok := c.cache.get(key, time.Now()); ok {
+			span.SetAttributes(cacheHitKey.Bool(true))
+			if fields == nil {
+				return nil, ErrNotFound
+			}
+			return copyFields(fields), nil
+		}
+	}
+
 	params := map[string][]string{}
 	if version > 0 {
 		params["version"] = []string{strconv.Itoa(version)}
 	}
-	secret, err := c.Client.Logical().ReadWithData(path, params)
+	start := time.Now()
+	secret, err := c.Client.Logical().ReadWithDataWithContext(ctx, toDataPath(path), params)
 	if err != nil {
-		return nil, err
+		observeRequest(operation, start, err)
+		return nil, classify(operation, path, err)
 	}
 	fields, err := getFieldsFromSecret(secret)
-	if err != nil {
+	observeRequest(operation, start, err)
+	if err == ErrNotFound {
+		if c.cache != nil {
+			c.cache.set(key, nil, time.Now())
+		}
 		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		c.cache.set(key, copyFields(fields), time.Now())
 	}
 	return fields, nil
 }
 
-func (c *Client) Get(path, field string, version int) (string, error) {
-	path = toDataPath(path)
-	params := map[string][]string{}
-	if version > 0 {
-		params["version"] = []string{strconv.Itoa(version)}
-	}
-	secret, err := c.Client.Logical().ReadWithData(path, params)
+func (c *Client) Get(ctx context.Context, path, field string, version int) (string, error) {
+	fields, err := c.getAll(ctx, "Get", path, version)
 	if err != nil {
 		return "", err
 	}
-	value, err := getFieldFromSecret(secret, field)
-	if err != nil {
-		return "", err
+	value, ok := fields[field]
+	if !ok {
+		return "", ErrNotFound
 	}
 	return value, nil
 }
 
-func (c *Client) CreateOrUpdate(path string, data map[string]interface{}) error {
-	path = toDataPath(path)
-	secret, err := c.Client.Logical().Read(path)
+// CreateOrUpdate merges the given fields into the latest version of the entry at path. The write fails
+// with ErrCASMismatch if the entry was modified since it was read and is skipped if nothing changes.
+func (c *Client) CreateOrUpdate(ctx context.Context, path string, data map[string]interface{}) (err error) {
+	ctx, span := startSpan(ctx, "CreateOrUpdate", path, 0)
+	defer func(start time.Time) {
+		observeRequest("CreateOrUpdate", start, err)
+		err = classify("CreateOrUpdate", path, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	current, version, err := c.readLatest(ctx, path)
 	if err != nil {
 		return err
 	}
+	if isNoop(current, data) {
+		return nil
+	}
+	span.SetAttributes(tracing.VersionKey.Int(version + 1))
+	return c.write(ctx, path, current, version, data)
+}
 
-	cas := 1
-	merged := map[string]interface{}{}
+// ConditionalWrite is a set of fields which is only written if its condition holds for the current
+// fields of the entry.
+type ConditionalWrite struct {
+	Condition func(fields map[string]string) bool
+	Data      map[string]interface{}
+}
+
+// FieldAbsent is a condition which holds if the field does not exist.
+func FieldAbsent(field string) func(fields map[string]string) bool {
+	return func(fields map[string]string) bool {
+		_, ok := fields[field]
+		return !ok
+	}
+}
+
+// FieldEquals is a condition which holds if the field has the expected value.
+func FieldEquals(field, expected string) func(fields map[string]string) bool {
+	return func(fields map[string]string) bool {
+		value, ok := fields[field]
+		return ok && value == expected
+	}
+}
+
+// WriteResult is the outcome of WriteConditional.
+type WriteResult struct {
+	// Fields of the entry after the write.
+	Fields map[string]string
+	// Applied tells which of the writes were applied.
+	Applied []bool
+	// Created is true if the entry did not exist and was created by the write.
+	Created bool
+}
+
+// WriteConditional writes all writes whose condition holds as a single new version of the entry at path.
+// If the entry is modified concurrently it is read again and the conditions are checked anew, so writers
+// racing for a field can not overwrite each other. Nothing is written if the applied writes do not change
+// the entry.
+func (c *Client) WriteConditional(ctx context.Context, path string, writes []ConditionalWrite) (_ *WriteResult, err error) {
+	ctx, span := startSpan(ctx, "WriteConditional", path, 0)
+	defer func(start time.Time) {
+		observeRequest("WriteConditional", start, err)
+		err = classify("WriteConditional", path, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	for i := 0; i < maxCASRetries; i++ {
+		current, version, err := c.readLatest(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		fields := toStringFields(current)
+
+		applied := make([]bool, len(writes))
+		data := map[string]interface{}{}
+		for j, w := range writes {
+			if !w.Condition(fields) {
+				continue
+			}
+			applied[j] = true
+			for k, v := range w.Data {
+				data[k] = v
+			}
+		}
+		if isNoop(current, data) {
+			return &WriteResult{Fields: fields, Applied: applied}, nil
+		}
+
+		err = c.write(ctx, path, current, version, data)
+		if errors.Is(err, ErrCASMismatch) {
+			c.log.V(1).Info("entry was modified concurrently, reading it again", "path", path, "version", version)
+			span.AddEvent("entry was modified concurrently", trace.WithAttributes(tracing.VersionKey.Int(version)))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for k, v := range toStringFields(data) {
+			fields[k] = v
+		}
+		span.SetAttributes(tracing.VersionKey.Int(version + 1))
+		// Writes with version zero only succeed if the entry does not exist yet
+		return &WriteResult{Fields: fields, Applied: applied, Created: version == 0}, nil
+	}
+	return nil, ErrCASMismatch
+}
+
+// DeleteFields removes the fields from the latest version of the entry at path by writing a new version
+// without them. Other fields and previous versions are kept. Nothing is written if none of the fields exist.
+func (c *Client) DeleteFields(ctx context.Context, path string, fields []string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteFields", path, 0)
+	defer func(start time.Time) {
+		observeRequest("DeleteFields", start, err)
+		err = classify("DeleteFields", path, err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	for i := 0; i < maxCASRetries; i++ {
+		current, version, err := c.readLatest(ctx, path)
+		if err != nil {
+			return err
+		}
+		remaining := map[string]interface{}{}
+		for k, v := range current {
+			remaining[k] = v
+		}
+		for _, field := range fields {
+			delete(remaining, field)
+		}
+		if version == 0 || len(remaining) == len(current) {
+			return nil
+		}
+
+		err = c.write(ctx, path, remaining, version, nil)
+		if errors.Is(err, ErrCASMismatch) {
+			c.log.V(1).Info("entry was modified concurrently, reading it again", "path", path, "version", version)
+			continue
+		}
+		return err
+	}
+	return ErrCASMismatch
+}
+
+// readLatest returns the fields of the latest version of the entry at path and its version, which is
+// zero if the entry does not exist.
+func (c *Client) readLatest(ctx context.Context, path string) (map[string]interface{}, int, error) {
+	secret, err := c.Client.Logical().ReadWithContext(ctx, toDataPath(path))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	current := map[string]interface{}{}
 	if secret != nil && secret.Data != nil {
 		if raw, ok := secret.Data["data"]; ok {
 			if data, ok := raw.(map[string]interface{}); ok {
 				for k, v := range data {
-					merged[k] = v
+					current[k] = v
 				}
 			}
 		}
@@ -119,35 +308,147 @@ func (c *Client) CreateOrUpdate(path string, data map[string]interface{}) error
 			if data, ok := raw.(map[string]interface{}); ok {
 				if v1, ok := data["version"]; ok {
 					if v2, ok := v1.(json.Number); ok {
-						version, err := v2.Int64()
+						v, err := v2.Int64()
 						if err == nil {
-							cas = int(version)
+							version = int(v)
 						}
 					}
 				}
 			}
 		}
 	}
+	return current, version, nil
+}
+
+// write merges data into current and writes it as the version following the given one. A version of
+// zero only succeeds if the entry does not exist yet.
+func (c *Client) write(ctx context.Context, path string, current map[string]interface{}, version int, data map[string]interface{}) error {
+	merged := map[string]interface{}{}
+	for k, v := range current {
+		merged[k] = v
+	}
 	for k, v := range data {
 		merged[k] = v
 	}
 	payload := map[string]interface{}{
 		"data": merged,
-		"cas":  cas,
+		"cas":  version,
+	}
+
+	_, err := c.Logical().WriteWithContext(ctx, toDataPath(path), payload)
+	if c.cache != nil {
+		c.cache.invalidate(c.Namespace(), strings.Trim(path, "/"))
+	}
+	if isCASMismatch(err) {
+		return ErrCASMismatch
 	}
+	return err
+}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
-	defer cancelFunc()
+// Destroy permanently deletes all versions and the metadata of the entry at path.
+func (c *Client) Destroy(ctx context.Context, path string) (err error) {
+	ctx, span := startSpan(ctx, "Destroy", path, 0)
+	defer func(start time.Time) {
+		observeRequest("Destroy", start, err)
+		err = classify("Destroy", path, err)
+		tracing.End(span, err)
+	}(time.Now())
 
-	_, err = c.Logical().WriteWithContext(ctx, path, payload)
-	if err != nil {
-		return err
+	_, err = c.Logical().DeleteWithContext(ctx, toMetadataPath(path))
+	if c.cache != nil {
+		c.cache.invalidate(c.Namespace(), strings.Trim(path, "/"))
+	}
+	return err
+}
+
+func isCASMismatch(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, e := range respErr.Errors {
+		if strings.Contains(e, "check-and-set") {
+			return true
+		}
+	}
+	return false
+}
+
+func copyFields(fields map[string]string) map[string]string {
+	copied := make(map[string]string, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return copied
+}
+
+// isNoop returns true if writing data would not change any of the current fields.
+func isNoop(current, data map[string]interface{}) bool {
+	for k, v := range data {
+		existing, ok := current[k].(string)
+		value, isString := v.(string)
+		if !ok || !isString || existing != value {
+			return false
+		}
+	}
+	return true
+}
+
+func toStringFields(data map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			fields[k] = s
+		}
 	}
-	return nil
+	return fields
 }
 
+// EnableRateLimit limits the requests to vault to qps per second with bursts of up to burst requests.
+// Requests are not limited if qps is zero.
+func (c *Client) EnableRateLimit(qps float64, burst int) {
+	if qps <= 0 {
+		return
+	}
+	c.SetLimiter(qps, burst)
+}
+
+// EnableCircuitBreaker stops sending requests for cooldown once threshold requests in a row failed.
+// Requests fail with an UnavailableError meanwhile. The breaker is disabled if threshold is zero.
+func (c *Client) EnableCircuitBreaker(threshold int, cooldown time.Duration) {
+	c.breaker.configure(threshold, cooldown)
+}
+
+// Close stops the renewal of the token.
 func (c *Client) Close() { c.tokenHandler.Close() }
 
+// relogin requests a new token, as vault rejected the current one.
+func (c *Client) relogin() {
+	if c.tokenHandler != nil {
+		c.tokenHandler.Relogin()
+	}
+}
+
+// forbiddenHook calls onForbidden if vault rejects a request other than a login with 403 Forbidden,
+// which is the response to requests with a revoked or expired token.
+type forbiddenHook struct {
+	base        http.RoundTripper
+	onForbidden func()
+}
+
+func (t *forbiddenHook) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusForbidden && !isLogin(req.URL.Path) {
+		t.onForbidden()
+	}
+	return resp, err
+}
+
+// isLogin returns whether the request path is a login of an auth method, e.g. /v1/auth/approle/login.
+func isLogin(path string) bool {
+	return strings.HasPrefix(path, "/v1/auth/") && strings.Contains(path, "/login")
+}
+
 func toDataPath(path string) string {
 	parts := strings.Split(path, "/")
 	if len(parts) < 2 {
@@ -157,20 +458,12 @@ func toDataPath(path string) string {
 	}
 }
 
-func getFieldFromSecret(secret *api.Secret, field string) (string, error) {
-	if secret == nil || secret.Data == nil || len(secret.Data) == 0 {
-		return "", ErrNotFound
-	}
-	if d1, ok := secret.Data["data"]; ok {
-		if d2, ok := d1.(map[string]interface{}); ok {
-			if v1, ok := d2[field]; ok {
-				if v2, ok := v1.(string); ok {
-					return v2, nil
-				}
-			}
-		}
+func toMetadataPath(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return path
 	}
-	return "", ErrNotFound
+	return strings.Join(append([]string{parts[0], "metadata"}, parts[1:]...), "/")
 }
 
 func getFieldsFromSecret(secret *api.Secret) (map[string]string, error) {
@@ -194,3 +487,12 @@ func getFieldsFromSecret(secret *api.Secret) (map[string]string, error) {
 func GetIsBinaryKey(key string) string {
 	return fmt.Sprintf(".%s_isBinary", key)
 }
+
+func GetPreviousKey(key string) string {
+	return fmt.Sprintf(".%s_previous", key)
+}
+
+// GetGeneratedByKey returns the hidden field recording which VaultSecret generated the value of key.
+func GetGeneratedByKey(key string) string {
+	return fmt.Sprintf(".%s_generatedBy", key)
+}
@@ -60,7 +60,7 @@ func NewDevServer() (*DevServer, error) {
 }
 
 func (s *DevServer) GetClient(namespace string) (*Client, error) {
-	return NewClient("http://"+s.addr, namespace, &TokenAuth{Token: s.rootToken})
+	return NewClient(context.Background(), "http://"+s.addr, namespace, &TokenAuth{Token: s.rootToken})
 }
 
 func (s *DevServer) Stop() error {
@@ -15,7 +15,13 @@
 package vault
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
 )
 
 var (
@@ -23,4 +29,76 @@ var (
 	ErrAuthMethodNotProvided = errors.New("method not provided")
 	ErrMissingToken          = errors.New("missing client token")
 	ErrNotFound              = errors.New("not found")
+	ErrCASMismatch           = errors.New("entry was modified concurrently")
+	ErrSealed                = errors.New("vault is sealed")
+	ErrNotInitialized        = errors.New("vault is not initialized")
+	ErrTokenExpired          = errors.New("vault token expired")
+	ErrUnavailable           = errors.New("vault is unavailable")
+	ErrForbidden             = errors.New("permission denied by vault")
+	ErrInvalidRequest        = errors.New("request rejected by vault")
 )
+
+// Error is a failed request to vault. Its class is one of ErrForbidden, ErrInvalidRequest, ErrSealed and
+// ErrUnavailable, so errors.Is tells how the request failed, while the cause is kept for errors.As.
+type Error struct {
+	// Op is the operation of the client which failed, e.g. GetAll.
+	Op string
+	// Path of the entry the operation was performed on.
+	Path  string
+	Class error
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s of %s failed: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) Is(target error) bool { return target == e.Class }
+
+// Permanent returns whether the request fails again if it is retried, unless the policies of the token or
+// the request are changed. Sealed or unavailable vaults are expected to recover.
+func (e *Error) Permanent() bool {
+	return e.Class == ErrForbidden || e.Class == ErrInvalidRequest
+}
+
+// IsPermanent returns whether err is a failed request to vault which fails again if it is retried.
+func IsPermanent(err error) bool {
+	var vaultErr *Error
+	return errors.As(err, &vaultErr) && vaultErr.Permanent()
+}
+
+// classify wraps a failed request of the operation in an Error. ErrNotFound, ErrCASMismatch and canceled
+// requests are returned as they are, as callers handle them.
+func classify(op, path string, err error) error {
+	if err == nil || err == ErrNotFound || errors.Is(err, ErrCASMismatch) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	// Connection errors and requests stopped by the circuit breaker are unavailable
+	class := ErrUnavailable
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		switch code := respErr.StatusCode; {
+		case code == http.StatusServiceUnavailable && isSealed(respErr):
+			class = ErrSealed
+		case code == http.StatusTooManyRequests || code >= http.StatusInternalServerError:
+			class = ErrUnavailable
+		case code == http.StatusForbidden:
+			class = ErrForbidden
+		default:
+			class = ErrInvalidRequest
+		}
+	}
+	return &Error{Op: op, Path: path, Class: class, Err: err}
+}
+
+func isSealed(respErr *api.ResponseError) bool {
+	for _, e := range respErr.Errors {
+		if strings.Contains(strings.ToLower(e), "sealed") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+func TestClassify(t *testing.T) {
+	for _, test := range []struct {
+		err       error
+		class     error
+		permanent bool
+	}{
+		{err: &api.ResponseError{StatusCode: http.StatusForbidden}, class: ErrForbidden, permanent: true},
+		{err: &api.ResponseError{StatusCode: http.StatusBadRequest}, class: ErrInvalidRequest, permanent: true},
+		{err: &api.ResponseError{StatusCode: http.StatusInternalServerError}, class: ErrUnavailable},
+		{err: &api.ResponseError{StatusCode: http.StatusTooManyRequests}, class: ErrUnavailable},
+		{err: &api.ResponseError{StatusCode: http.StatusServiceUnavailable}, class: ErrUnavailable},
+		{err: &api.ResponseError{StatusCode: http.StatusServiceUnavailable, Errors: []string{"Vault is sealed"}}, class: ErrSealed},
+		{err: &UnavailableError{}, class: ErrUnavailable},
+		{err: fmt.Errorf("dial tcp: connection refused"), class: ErrUnavailable},
+	} {
+		err := classify("GetAll", "app/test/foo", test.err)
+		var vaultErr *Error
+		if !errors.As(err, &vaultErr) {
+			t.Fatalf("expected %v to be wrapped, got %T", test.err, err)
+		}
+		if !errors.Is(err, test.class) {
+			t.Errorf("expected %v to be classified as %v", test.err, test.class)
+		}
+		if errors.Cause(vaultErr.Unwrap()) != test.err {
+			t.Errorf("expected cause %v to be kept", test.err)
+		}
+		if IsPermanent(err) != test.permanent {
+			t.Errorf("expected %v to be permanent: %v", test.err, test.permanent)
+		}
+	}
+
+	// Errors handled by the callers are not wrapped
+	for _, err := range []error{nil, ErrNotFound, ErrCASMismatch, context.Canceled} {
+		if classified := classify("GetAll", "app/test/foo", err); classified != err {
+			t.Errorf("expected %v to be returned as it is, got %v", err, classified)
+		}
+	}
+}
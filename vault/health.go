@@ -0,0 +1,116 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HealthChecker checks the connection to vault and the validity of the token for the probes of the
+// operator. Results are cached, so frequent probes do not put load on vault.
+type HealthChecker struct {
+	client *Client
+	// cacheTTL is how long the result of a check is reused.
+	cacheTTL time.Duration
+	// threshold is how long vault may be unavailable before the operator is reported as not ready.
+	threshold time.Duration
+	now       func() time.Time
+	probe     func(ctx context.Context) error
+
+	mu           sync.Mutex
+	checked      time.Time
+	err          error
+	failingSince time.Time
+}
+
+// NewHealthChecker returns a HealthChecker caching results for cacheTTL and tolerating an unavailable
+// vault for threshold.
+func (c *Client) NewHealthChecker(cacheTTL, threshold time.Duration) *HealthChecker {
+	h := &HealthChecker{client: c, cacheTTL: cacheTTL, threshold: threshold, now: time.Now}
+	h.probe = h.check
+	return h
+}
+
+// Ready implements healthz.Checker. It fails immediately if vault is sealed or not initialized and once
+// vault is unreachable or rejects the token for longer than the threshold.
+func (h *HealthChecker) Ready(req *http.Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if h.checked.IsZero() || now.Sub(h.checked) >= h.cacheTTL {
+		h.err = h.probe(req.Context())
+		h.checked = now
+		switch {
+		case h.err == nil:
+			h.failingSince = time.Time{}
+		case h.failingSince.IsZero():
+			h.failingSince = now
+		}
+	}
+
+	switch {
+	case h.err == nil:
+		return nil
+	case errors.Is(h.err, ErrSealed) || errors.Is(h.err, ErrNotInitialized):
+		return h.err
+	case now.Sub(h.failingSince) >= h.threshold:
+		return fmt.Errorf("vault unavailable since %s: %w", h.failingSince.Format(time.RFC3339), h.err)
+	}
+	return nil
+}
+
+// Live implements healthz.Checker. It fails if the token expired longer than the threshold ago and could
+// not be replaced since, as the token handler is then assumed to be stuck. The state of the token handler
+// is part of the error.
+func (h *HealthChecker) Live(_ *http.Request) error {
+	state := h.client.tokenHandler.State()
+	if state.Expires.IsZero() || h.now().Sub(state.Expires) < h.threshold {
+		return nil
+	}
+	return fmt.Errorf("%w at %s, last login %s, last renewal %s, last error %v",
+		ErrTokenExpired, formatTime(state.Expires), formatTime(state.LastLogin), formatTime(state.LastRenewal), state.LastError)
+}
+
+// check asks vault for its health and looks up the token.
+func (h *HealthChecker) check(ctx context.Context) error {
+	health, err := h.client.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !health.Initialized:
+		return ErrNotInitialized
+	case health.Sealed:
+		return ErrSealed
+	}
+	if _, err := h.client.Auth().Token().LookupSelfWithContext(ctx); err != nil {
+		return errors.Wrap(err, "token lookup failed")
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckerReady(t *testing.T) {
+	now := time.Now()
+	var probeErr error
+	probes := 0
+	h := &HealthChecker{cacheTTL: 10 * time.Second, threshold: time.Minute, now: func() time.Time { return now }}
+	h.probe = func(context.Context) error {
+		probes++
+		return probeErr
+	}
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	if err := h.Ready(req); err != nil {
+		t.Fatalf("expected ready, got %v", err)
+	}
+	if err := h.Ready(req); err != nil || probes != 1 {
+		t.Fatalf("expected cached result, got %v after %d probes", err, probes)
+	}
+
+	probeErr = errors.New("connection refused")
+	now = now.Add(10 * time.Second)
+	if err := h.Ready(req); err != nil {
+		t.Errorf("expected unavailable vault to be tolerated, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := h.Ready(req); err == nil {
+		t.Error("expected not ready after the threshold")
+	}
+
+	probeErr = nil
+	now = now.Add(10 * time.Second)
+	if err := h.Ready(req); err != nil {
+		t.Errorf("expected ready once vault is available again, got %v", err)
+	}
+
+	probeErr = ErrSealed
+	now = now.Add(10 * time.Second)
+	if err := h.Ready(req); !errors.Is(err, ErrSealed) {
+		t.Errorf("expected sealed vault to be not ready immediately, got %v", err)
+	}
+}
+
+func TestHealthCheckerLive(t *testing.T) {
+	now := time.Now()
+	handler := &TokenHandler{}
+	h := &HealthChecker{client: &Client{tokenHandler: handler}, threshold: time.Minute, now: func() time.Time { return now }}
+	req := httptest.NewRequest("GET", "/healthz", nil)
+
+	if err := h.Live(req); err != nil {
+		t.Errorf("expected token without expiry to be live, got %v", err)
+	}
+	handler.state = TokenState{LastLogin: now.Add(-time.Hour), Expires: now.Add(-30 * time.Second)}
+	if err := h.Live(req); err != nil {
+		t.Errorf("expected recently expired token to be tolerated, got %v", err)
+	}
+	handler.state.Expires = now.Add(-2 * time.Minute)
+	handler.state.LastError = errors.New("permission denied")
+	if err := h.Live(req); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected expired token to fail, got %v", err)
+	}
+}
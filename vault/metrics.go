@@ -0,0 +1,109 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vaultoperator_vault_requests_total",
+		Help: "Number of requests to vault by operation and status code.",
+	}, []string{"operation", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "vaultoperator_vault_request_duration_seconds",
+		Help:    "Latency of requests to vault by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	tokenTTL = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "vaultoperator_vault_token_ttl_seconds",
+		Help: "Time until the vault token expires, +Inf if it does not expire.",
+	}, tokenExpiry.remaining)
+
+	circuitOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "vaultoperator_vault_circuit_open",
+		Help: "Whether requests to vault are stopped by the circuit breaker.",
+	})
+
+	tokenExpiry = &expiry{}
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestsTotal, requestDuration, tokenTTL, circuitOpen)
+}
+
+// expiry is the time the current token expires.
+type expiry struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+// set records that the token expires after ttl, zero means it does not expire.
+func (e *expiry) set(ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ttl <= 0 {
+		e.at = time.Time{}
+		return
+	}
+	e.at = time.Now().Add(ttl)
+}
+
+func (e *expiry) remaining() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.at.IsZero() {
+		return math.Inf(1)
+	}
+	return math.Max(0, time.Until(e.at).Seconds())
+}
+
+// observeRequest records a request to vault of the operation started at start.
+func observeRequest(operation string, start time.Time, err error) {
+	requestDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	requestsTotal.WithLabelValues(operation, statusCode(err)).Inc()
+}
+
+// statusCode returns the HTTP status code of the response which resulted in err, "unavailable" if the
+// request was stopped by the circuit breaker, or "error" if there was no response.
+func statusCode(err error) string {
+	var (
+		respErr     *api.ResponseError
+		unavailable *UnavailableError
+	)
+	switch {
+	case err == nil:
+		return strconv.Itoa(http.StatusOK)
+	case errors.Is(err, ErrNotFound):
+		return strconv.Itoa(http.StatusNotFound)
+	case errors.Is(err, ErrCASMismatch):
+		return strconv.Itoa(http.StatusBadRequest)
+	case errors.As(err, &unavailable):
+		return "unavailable"
+	case errors.As(err, &respErr):
+		return strconv.Itoa(respErr.StatusCode)
+	}
+	return "error"
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestStatusCode(t *testing.T) {
+	for err, expected := range map[error]string{
+		nil:            "200",
+		ErrNotFound:    "404",
+		ErrCASMismatch: "400",
+		fmt.Errorf("wrapped: %w", &api.ResponseError{StatusCode: 403}):          "403",
+		fmt.Errorf("connection refused"):                                        "error",
+		&Error{Class: ErrUnavailable, Err: &api.ResponseError{StatusCode: 503}}: "503",
+		&Error{Class: ErrUnavailable, Err: &UnavailableError{}}:                 "unavailable",
+	} {
+		if code := statusCode(err); code != expected {
+			t.Errorf("expected %s for %v, got %s", expected, err, code)
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	e := &expiry{}
+	if remaining := e.remaining(); !math.IsInf(remaining, 1) {
+		t.Errorf("expected unknown expiry to be infinite, got %v", remaining)
+	}
+	e.set(time.Hour)
+	if remaining := e.remaining(); remaining <= 3590 || remaining > 3600 {
+		t.Errorf("expected about an hour, got %v", remaining)
+	}
+	e.set(0)
+	if remaining := e.remaining(); !math.IsInf(remaining, 1) {
+		t.Errorf("expected token without TTL to be infinite, got %v", remaining)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+)
+
+const (
+	minRetryWait = 250 * time.Millisecond
+	maxRetryWait = 10 * time.Second
+)
+
+// retryPolicy retries requests like the vault api and also throttled requests, but not requests which
+// were stopped by the circuit breaker.
+func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if errors.Is(err, ErrUnavailable) {
+		return false, nil
+	}
+	if ctx.Err() == nil && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	return api.DefaultRetryPolicy(ctx, resp, err)
+}
+
+// retryBackoff doubles the wait between retries up to max with random jitter, so operators restarting
+// at the same time do not retry in lockstep. The Retry-After header of throttled responses is honored.
+func retryBackoff(min, max time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if wait := time.Duration(seconds) * time.Second; wait < max {
+				return wait
+			}
+			return max
+		}
+	}
+	wait := max
+	if attempt < 32 {
+		if w := min << uint(attempt); w > 0 && w < max {
+			wait = w
+		}
+	}
+	if wait <= min {
+		return wait
+	}
+	return min + time.Duration(rand.Int63n(int64(wait-min)))
+}
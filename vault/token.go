@@ -17,130 +17,292 @@ package vault
 // Initial version from: authn-authz/customer-credential-service/blob/develop/pkg/vault/vault.go
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/finleap-connect/vaultoperator/tracing"
+)
+
+const (
+	// minReloginInterval limits how often rejected requests cause a login, as vault also rejects requests
+	// of valid tokens which are not allowed by their policies.
+	minReloginInterval = 10 * time.Second
+	// maxLoginBackOff is the maximum delay between failed logins.
+	maxLoginBackOff = time.Minute
 )
 
 // TokenHandler automatically deals with the renewal of tokens used for authentication with the
 // Vault API. It uses the AuthMethod to generate new tokens if required (e.g. if the current token
-// is not renewable anymore).
+// is not renewable anymore or was revoked). It stops once its context is done or it is closed.
 type TokenHandler struct {
 	client *Client
 	method AuthMethod
 	log    logr.Logger
-	tokens chan string
+	// ready is closed once the first token was obtained.
+	ready     chan struct{}
+	readyOnce sync.Once
+	// relogin requests an immediate login.
+	relogin chan struct{}
+	// done is closed once the renewal loop stopped.
+	done       chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	newBackOff func() backoff.BackOff
+	// reloginInterval is the minimum time between a login and a login requested by Relogin.
+	reloginInterval time.Duration
+
+	mu    sync.Mutex
+	state TokenState
+}
 
-	mu      sync.Mutex
-	closed  bool
-	renewer *api.Renewer
+// TokenState describes the current token of a TokenHandler and the outcome of the last login or renewal.
+type TokenState struct {
+	// LastLogin is when the current token was obtained, zero before the first login.
+	LastLogin time.Time
+	// LastRenewal is when the current token was last renewed, zero if it was not renewed yet.
+	LastRenewal time.Time
+	// Expires is when the current token expires, zero if it does not expire or is not known.
+	Expires time.Time
+	// LastError is the error of the last login or renewal, nil if it succeeded.
+	LastError error
+	// LastErrorTime is when LastError occurred.
+	LastErrorTime time.Time
 }
 
-// NewTokenHandler creates a new TokenHandler.
-func NewTokenHandler(c *Client, m AuthMethod) *TokenHandler {
+// NewTokenHandler creates a new TokenHandler, which stops once the context is done.
+func NewTokenHandler(ctx context.Context, c *Client, m AuthMethod) *TokenHandler {
+	ctx, cancel := context.WithCancel(ctx)
 	h := &TokenHandler{
-		client: c,
-		method: m,
-		log:    c.log.WithName("TokenHandler"),
-		tokens: make(chan string),
-	}
-	if h.method.IsRenewable() {
-		go h.run()
+		client:     c,
+		method:     m,
+		log:        c.log.WithName("TokenHandler"),
+		ready:      make(chan struct{}),
+		relogin:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		newBackOff: newLoginBackOff,
+
+		reloginInterval: minReloginInterval,
 	}
 	return h
 }
 
-// WaitForToken blocks until a renewed token or the initial token has been received. It returns an
-// error if no token is received before the timeout is reached.
-func (h *TokenHandler) WaitForToken(timeout time.Duration) error {
+// Start logs in and renews tokens in the background, if the auth method provides renewable tokens.
+// It has to be called once before the handler is used.
+func (h *TokenHandler) Start() {
 	if h.method.IsRenewable() {
-		select {
-		case <-h.tokens:
-		case <-time.After(timeout):
-			return ErrTimeout
-		}
+		go h.run(h.ctx)
 	} else {
-		secret, err := h.method.Login(h.client)
+		close(h.done)
+	}
+}
+
+// newLoginBackOff returns the exponential backoff with jitter between failed logins. It never gives up.
+func newLoginBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.MaxInterval = maxLoginBackOff
+	b.MaxElapsedTime = 0
+	return b
+}
+
+// WaitForToken blocks until the initial token has been received. It returns an error if no token is
+// received before the timeout is reached, the renewal loop is stopped in that case.
+func (h *TokenHandler) WaitForToken(timeout time.Duration) error {
+	if !h.method.IsRenewable() {
+		secret, err := h.login(context.Background())
 		if err != nil {
 			return err
 		}
 		h.client.SetToken(secret.Auth.ClientToken)
+		h.lookupTTL()
+		return nil
+	}
+
+	select {
+	case <-h.ready:
+		return nil
+	case <-h.done:
+		return context.Canceled
+	case <-time.After(timeout):
+		h.Close()
+		return ErrTimeout
 	}
-	return nil
 }
 
-// Close the token handler and stop the background renewal process.
-func (h *TokenHandler) Close() {
+// State returns the state of the current token.
+func (h *TokenHandler) State() TokenState {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	return h.state
+}
 
-	h.closed = true
-	if h.renewer != nil {
-		h.renewer.Stop()
+// Relogin requests a new token without waiting for the current one to expire, e.g. because vault
+// rejected it. Requests shortly after the last login are ignored.
+func (h *TokenHandler) Relogin() {
+	if !h.method.IsRenewable() {
+		return
 	}
+	h.mu.Lock()
+	recent := time.Since(h.state.LastLogin) < h.reloginInterval
+	h.mu.Unlock()
+	if recent {
+		return
+	}
+	select {
+	case h.relogin <- struct{}{}:
+	default:
+	}
+}
+
+// Close the token handler and wait for the background renewal process to stop.
+func (h *TokenHandler) Close() {
+	h.cancel()
+	<-h.done
 }
 
-func (h *TokenHandler) run() {
+// run logs in and renews the token until the context is done. Failed logins are retried with backoff.
+func (h *TokenHandler) run(ctx context.Context) {
+	defer close(h.done)
 	h.log.Info("Starting token renewal loop.")
-	for {
-		h.mu.Lock()
-		if h.closed {
-			h.mu.Unlock()
-			break
-		}
-		h.mu.Unlock()
 
-		secret, err := h.method.Login(h.client)
-		if err != nil {
-			h.log.Error(err, "Failed to request client token")
-			time.Sleep(500 * time.Millisecond)
-			continue
+	b := h.newBackOff()
+	for {
+		err := h.loginAndRenew(ctx)
+		if ctx.Err() != nil {
+			h.log.Info("Stopped token renewal loop.")
+			return
 		}
-		if secret == nil {
-			h.log.Info("Received empty token response. Retrying...")
-			time.Sleep(500 * time.Millisecond)
+		if err == nil {
+			b.Reset()
 			continue
 		}
 
-		token := secret.Auth.ClientToken
-		h.log.V(2).Info("Setting client-token", "token", token)
-		h.client.SetToken(token)
-
+		wait := b.NextBackOff()
+		h.log.Error(err, "Failed to obtain or renew client token, retrying.", "after", wait)
 		select {
-		case h.tokens <- token:
-		default:
+		case <-ctx.Done():
+			h.log.Info("Stopped token renewal loop.")
+			return
+		case <-time.After(wait):
 		}
+	}
+}
 
-		renewer, err := h.client.NewLifetimeWatcher(&api.RenewerInput{Secret: secret})
-		if err != nil {
-			h.log.Error(err, "Failed to start token renewer.")
-		}
-		h.mu.Lock()
-		h.renewer = renewer
-		h.mu.Unlock()
+// loginAndRenew obtains a new token and renews it until it can not be renewed anymore, a new login is
+// requested or the context is done.
+func (h *TokenHandler) loginAndRenew(ctx context.Context) error {
+	secret, err := h.login(ctx)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil {
+		return ErrMissingToken
+	}
+	h.client.SetToken(secret.Auth.ClientToken)
+	h.log.V(1).Info("Obtained client token.", "accessor", secret.Auth.Accessor, "lease", time.Duration(secret.Auth.LeaseDuration)*time.Second)
+	h.readyOnce.Do(func() { close(h.ready) })
 
-		go h.renewer.Renew()
-		h.monitorRenewal()
+	watcher, err := h.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		return err
 	}
-}
+	go watcher.Start()
+	defer watcher.Stop()
 
-func (h *TokenHandler) monitorRenewal() {
 	for {
 		select {
-		case err := <-h.renewer.DoneCh():
+		case <-ctx.Done():
+			return nil
+		case <-h.relogin:
+			h.log.Info("Client token was rejected, logging in again.", "accessor", secret.Auth.Accessor)
+			return nil
+		case err := <-watcher.DoneCh():
 			if err != nil {
-				h.log.Error(err, "Vault token renewer returned error.")
+				requestsTotal.WithLabelValues("renew", statusCode(err)).Inc()
+				h.recordError(err)
+				return err
 			}
-			return
-		case result := <-h.renewer.RenewCh():
+			// The token reached its maximum TTL
+			return nil
+		case result := <-watcher.RenewCh():
+			requestsTotal.WithLabelValues("renew", statusCode(nil)).Inc()
+			h.recordToken(true, time.Duration(result.Secret.Auth.LeaseDuration)*time.Second)
 			h.log.V(2).Info(
 				"Renewed Vault client token.",
-				"token", result.Secret.Auth.ClientToken,
+				"accessor", secret.Auth.Accessor,
 				"lease", time.Duration(result.Secret.Auth.LeaseDuration)*time.Second,
 				"renewable", result.Secret.Auth.Renewable,
 			)
 		}
 	}
 }
+
+// login requests a new token with the auth method and records when it expires.
+func (h *TokenHandler) login(ctx context.Context) (*api.Secret, error) {
+	ctx, span := tracer.Start(ctx, "vault.login", trace.WithAttributes(authMethodKey.String(h.method.Name())))
+	start := time.Now()
+	secret, err := h.method.Login(ctx, h.client)
+	observeRequest("login", start, err)
+	tracing.End(span, err)
+	if err != nil {
+		h.recordError(err)
+	} else if secret != nil && secret.Auth != nil {
+		h.recordToken(false, time.Duration(secret.Auth.LeaseDuration)*time.Second)
+	}
+	return secret, err
+}
+
+// lookupTTL records when the configured token expires, as it is not known without asking vault.
+func (h *TokenHandler) lookupTTL() {
+	secret, err := h.client.Auth().Token().LookupSelf()
+	if err != nil {
+		h.log.Error(err, "Failed to look up the TTL of the token.")
+		return
+	}
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		h.log.Error(err, "Failed to read the TTL of the token.")
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.setExpiry(ttl)
+}
+
+// recordToken records that a token valid for ttl was obtained by a login or renewal.
+func (h *TokenHandler) recordToken(renewal bool, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	if renewal {
+		h.state.LastRenewal = now
+	} else {
+		h.state.LastLogin, h.state.LastRenewal = now, time.Time{}
+	}
+	h.setExpiry(ttl)
+	h.state.LastError, h.state.LastErrorTime = nil, time.Time{}
+}
+
+// recordError records a failed login or renewal.
+func (h *TokenHandler) recordError(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.state.LastError, h.state.LastErrorTime = err, time.Now()
+}
+
+// setExpiry records that the current token expires after ttl, zero means it does not expire. The caller
+// has to hold the lock.
+func (h *TokenHandler) setExpiry(ttl time.Duration) {
+	tokenExpiry.set(ttl)
+	h.state.Expires = time.Time{}
+	if ttl > 0 {
+		h.state.Expires = time.Now().Add(ttl)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+)
+
+// newLoginServer returns a vault answering logins with numbered tokens after failing the given number
+// of logins. The number of logins is counted in logins.
+func newLoginServer(t *testing.T, failures int32, logins *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(logins, 1)
+		if n <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"auth": {"client_token": "token-%d", "accessor": "accessor", "lease_duration": 3600, "renewable": true}}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestTokenHandler(t *testing.T, ctx context.Context, addr string) (*Client, *TokenHandler) {
+	c := &Client{log: logr.Discard()}
+	cfg := api.DefaultConfig()
+	cfg.Address = addr
+	cfg.MaxRetries = 0
+	var err error
+	if c.Client, err = api.NewClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	h := NewTokenHandler(ctx, c, &AppRoleAuth{RoleID: "role", SecretID: "secret"})
+	h.newBackOff = func() backoff.BackOff { return backoff.NewConstantBackOff(time.Millisecond) }
+	h.reloginInterval = 0
+	c.tokenHandler = h
+	return c, h
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTokenHandlerRetriesAndRelogins(t *testing.T) {
+	var logins int32
+	server := newLoginServer(t, 2, &logins)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, h := newTestTokenHandler(t, ctx, server.URL)
+	h.Start()
+
+	if err := h.WaitForToken(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if token := c.Token(); token != "token-3" {
+		t.Errorf("expected token after two failed logins, got %q", token)
+	}
+	if state := h.State(); state.LastLogin.IsZero() || state.LastError != nil || state.Expires.IsZero() {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	c.relogin()
+	waitFor(t, func() bool { return c.Token() == "token-4" })
+
+	cancel()
+	select {
+	case <-h.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected renewal loop to stop with the context")
+	}
+}
+
+func TestTokenHandlerStopsOnTimeout(t *testing.T) {
+	var logins int32
+	server := newLoginServer(t, 1<<30, &logins)
+	_, h := newTestTokenHandler(t, context.Background(), server.URL)
+	h.Start()
+
+	if err := h.WaitForToken(50 * time.Millisecond); err != ErrTimeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	select {
+	case <-h.done:
+	default:
+		t.Fatal("expected renewal loop to be stopped")
+	}
+	if h.State().LastError == nil {
+		t.Error("expected failed login to be recorded")
+	}
+}
+
+func TestForbiddenHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	var forbidden int
+	hook := &forbiddenHook{base: http.DefaultTransport, onForbidden: func() { forbidden++ }}
+	for _, path := range []string{"/v1/app/data/test/db", "/v1/auth/token/lookup-self", "/v1/auth/approle/login"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		resp, err := hook.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if forbidden != 2 {
+		t.Errorf("expected rejected requests other than logins to trigger a login, got %d", forbidden)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/finleap-connect/vaultoperator/tracing"
+)
+
+var tracer = tracing.Tracer("vault")
+
+const (
+	// cacheHitKey marks reads answered from the cache of the client.
+	cacheHitKey = attribute.Key("vault.cache_hit")
+	// authMethodKey is the name of the auth method used to log in.
+	authMethodKey = attribute.Key("vault.auth_method")
+)
+
+// startSpan starts a span of the operation on the entry at path. Version zero refers to the latest version.
+func startSpan(ctx context.Context, operation, path string, version int) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{tracing.PathKey.String(strings.Trim(path, "/"))}
+	if version > 0 {
+		attrs = append(attrs, tracing.VersionKey.Int(version))
+	}
+	return tracer.Start(ctx, "vault."+operation, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// ignoreNotFound returns nil for ErrNotFound, as missing entries are expected and not an error of the span.
+func ignoreNotFound(err error) error {
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 VaultOperator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/finleap-connect/vaultoperator/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/app/data/test/db" {
+			traceparent = r.Header.Get("traceparent")
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "secret"}, "metadata": {"version": 2}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c, err := NewClient(context.Background(), server.URL, "", &TokenAuth{Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	if _, err := c.Get(ctx, "app/test/db", "password", 2); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	if !strings.Contains(traceparent, parent.SpanContext().TraceID().String()) {
+		t.Errorf("expected trace context to be propagated, got %q", traceparent)
+	}
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.Name() != "vault.Get" {
+			continue
+		}
+		found = true
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected span to be a child of the caller")
+		}
+		attrs := map[string]string{}
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+			if strings.Contains(attr.Value.Emit(), "secret") {
+				t.Errorf("value leaked into attribute %s", attr.Key)
+			}
+		}
+		if attrs[string(tracing.PathKey)] != "app/test/db" || attrs[string(tracing.VersionKey)] != "2" {
+			t.Errorf("unexpected attributes %v", attrs)
+		}
+	}
+	if !found {
+		t.Error("expected span of the read")
+	}
+}